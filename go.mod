@@ -2,6 +2,7 @@ module paracast_cabi
 
 go 1.24.5
 
-require github.com/openfluke/paragon/v3 v3.1.4
-
-require github.com/openfluke/webgpu v0.0.1 // indirect
+require (
+	github.com/openfluke/paragon/v3 v3.1.4
+	github.com/openfluke/webgpu v0.0.1
+)
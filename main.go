@@ -3,6 +3,7 @@ package main
 /*
 #include <stdlib.h>
 #include <stdbool.h>
+#include <stdint.h>
 
 // Ensure bool/true/false are available
 #ifndef __cplusplus
@@ -12,497 +13,7787 @@ package main
 #define false 0
 #endif
 #endif
+
+// Completion callback of Paragon_ForwardBatchAsync: data holds length floats
+// on success, or is NULL with err set to the failure message.
+typedef void (*paragon_batch_cb)(int64_t handle, const float* data, int length, const char* err);
+
+static inline void call_batch_cb(uintptr_t cb, int64_t handle, const float* data, int length, const char* err) {
+	((paragon_batch_cb)cb)(handle, data, length, err);
+}
+
+// Hook set by Paragon_SetCallHook; phase is "before" or "after".
+typedef void (*paragon_call_hook)(int64_t handle, const char* method, const char* phase);
+
+static inline void call_call_hook(uintptr_t cb, int64_t handle, const char* method, const char* phase) {
+	((paragon_call_hook)cb)(handle, method, phase);
+}
+
+// Activation registered by Paragon_RegisterActivation: writes f(in[i]) to
+// out[i] for a whole layer at a time.
+typedef void (*paragon_activation_cb)(const float* in, float* out, int n);
+
+static inline void call_activation_cb(uintptr_t cb, const float* in, float* out, int n) {
+	((paragon_activation_cb)cb)(in, out, n);
+}
 */
 import "C"
 
 import (
+	"bufio"
+	"bytes"
+	"container/heap"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	randv2 "math/rand/v2"
+	"os"
+	"path/filepath"
 	"reflect"
+	"regexp"
+	"runtime"
+	"slices"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"text/tabwriter"
 	"time"
 	"unsafe"
 
 	"github.com/openfluke/paragon/v3"
+	"github.com/openfluke/webgpu/wgpu"
+)
+
+// C ABI version reported by Paragon_GetVersion and checked by Paragon_CheckABI.
+const (
+	abiMajor = 1
+	abiMinor = 1
 )
 
+// entry is a registry slot: the Go object plus any bridge-side state kept
+// alongside it for the lifetime of the handle.
+type entry struct {
+	obj      interface{}
+	created  time.Time
+	lastUsed time.Time
+
+	// refs counts the handle's owners: one from creation plus one per
+	// Paragon_Retain, each dropped by Paragon_Free. Guarded by mu.
+	refs int
+
+	// lock serializes reflected calls that may mutate obj (pointer
+	// receivers) while letting value-receiver calls share it. Networks made
+	// by Paragon_CreateFromTemplate with shared weights share one lock.
+	lock *sync.RWMutex
+
+	// Persistent output buffer for Paragon_ForwardReuse (C memory).
+	outBuf *C.float
+	outLen int
+
+	// Per-layer activation parameters set by Paragon_SetActivationParameters.
+	actParams map[int]map[string]float64
+
+	// Flattened input of the most recent forward export.
+	lastInput []float64
+
+	// Retry forward-family reflected calls on the CPU if the GPU fails.
+	gpuFallback bool
+
+	// Training state for Paragon_TrainStep: the global gradient-norm cap (0
+	// disables clipping) and the norms of the most recent step.
+	gradClip    float64
+	gradNorm    float64
+	rawGradNorm float64
+	trained     bool
+
+	// Optimizer set by Paragon_SetOptimizer; nil means plain SGD.
+	opt *optimizer
+
+	// L2 coefficient set by Paragon_SetWeightDecay; 0 disables it.
+	weightDecay float64
+
+	// Layers Paragon_SetLayerFrozen excluded from training.
+	frozen map[int]bool
+
+	// Output labels set by Paragon_SetClassNames, one per output neuron.
+	classNames []string
+
+	// Training loss set by Paragon_SetLossFunction; "" is the default.
+	loss string
+
+	// DropConnect set by Paragon_SetDropConnect; a rate of 0 disables it.
+	dropRate float64
+	dropRNG  *rand.Rand
+
+	// Per-layer scales of a Network[int8] made by Paragon_Quantize.
+	quant *quantization
+
+	// Forward passes served: forward exports and reflected Forward* calls.
+	forwards atomic.Int64
+
+	// Nanoseconds spent in those forward passes while on the GPU, and the
+	// point Paragon_GetGPUUtilization last sampled it at.
+	gpuBusy  atomic.Int64
+	utilAt   time.Time
+	utilBusy int64
+
+	// evalMode is set by Paragon_SetMode("eval").
+	evalMode bool
+
+	// Activation ranges from Paragon_StartCalibration; nil if never started.
+	calib *calibration
+
+	// Tags set by Paragon_SetMetadata, guarded by metaMu so they can be read
+	// while a long call holds lock.
+	metadata map[string]string
+
+	// Most recent failure on this handle, guarded by errMu.
+	lastErr     string
+	lastErrTime time.Time
+}
+
 var (
 	mu      sync.Mutex
 	nextID  int64 = 1
-	objects       = map[int64]interface{}{}
+	objects       = map[int64]*entry{}
+
+	errMu   sync.Mutex
+	lastErr string
+
+	metaMu sync.Mutex
+
+	// namespaces maps each live Paragon_NewNamespace token to its next local
+	// ID.
+	namespaces       = map[int64]int64{}
+	nextNS     int64 = 1
+
+	// reserved holds IDs from Paragon_ReserveHandles not yet filled.
+	reserved = map[int64]bool{}
 )
 
+// Handles in a namespace are token<<namespaceShift | local ID, with local IDs
+// counted per namespace; the default namespace 0 uses nextID, so its handles
+// are the plain small integers they have always been.
+const namespaceShift = 32
+
+func namespaceOf(id int64) int64 { return id >> namespaceShift }
+
 func put(o interface{}) int64 {
+	id, _ := putIn(0, o)
+	return id
+}
+
+// putIn registers o in namespace ns, failing if ns is not live.
+func putIn(ns int64, o interface{}) (int64, bool) {
+	return putInLocked(ns, o, new(sync.RWMutex))
+}
+
+// putInLocked is putIn for an object guarded by an existing handle lock.
+func putInLocked(ns int64, o interface{}, lock *sync.RWMutex) (int64, bool) {
 	mu.Lock()
 	defer mu.Unlock()
-	id := nextID
-	nextID++
-	objects[id] = o
-	return id
+	var id int64
+	if ns == 0 {
+		id = nextID
+		nextID++
+	} else {
+		local, ok := namespaces[ns]
+		if !ok {
+			return 0, false
+		}
+		namespaces[ns] = local + 1
+		id = ns<<namespaceShift | local
+	}
+	now := time.Now()
+	objects[id] = &entry{obj: o, lock: lock, refs: 1, created: now, lastUsed: now}
+	return id, true
+}
+
+// putReserved registers o under an ID reserved by Paragon_ReserveHandles,
+// failing if id is not an unfilled reservation.
+func putReserved(id int64, o interface{}) bool {
+	mu.Lock()
+	defer mu.Unlock()
+	if !reserved[id] {
+		return false
+	}
+	delete(reserved, id)
+	now := time.Now()
+	objects[id] = &entry{obj: o, lock: new(sync.RWMutex), refs: 1, created: now, lastUsed: now}
+	return true
+}
+
+// touch marks a handle as used now, for idle eviction.
+func touch(id int64) {
+	mu.Lock()
+	defer mu.Unlock()
+	if e, ok := objects[id]; ok {
+		e.lastUsed = time.Now()
+	}
 }
 
 func get(id int64) (interface{}, bool) {
 	mu.Lock()
 	defer mu.Unlock()
-	o, ok := objects[id]
-	return o, ok
+	e, ok := objects[id]
+	if !ok {
+		return nil, false
+	}
+	return e.obj, true
+}
+
+func getEntry(id int64) (*entry, bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	e, ok := objects[id]
+	return e, ok
 }
 
 func del(id int64) {
 	mu.Lock()
 	defer mu.Unlock()
+	if e, ok := objects[id]; ok && e.outBuf != nil {
+		C.free(unsafe.Pointer(e.outBuf))
+	}
 	delete(objects, id)
+	delete(reserved, id)
+}
+
+func getNetwork(handle int64) (*paragon.Network[float32], error) {
+	obj, ok := get(handle)
+	if !ok {
+		return nil, fmt.Errorf("invalid handle %d", handle)
+	}
+	net, ok := obj.(*paragon.Network[float32])
+	if !ok {
+		return nil, fmt.Errorf("not a Network[float32]")
+	}
+	return net, nil
+}
+
+// lockNetwork is getNetwork for exports that mutate the network directly: it
+// also takes the handle's write lock, which the caller must release.
+func lockNetwork(handle int64) (*paragon.Network[float32], func(), error) {
+	e, ok := getEntry(handle)
+	if !ok {
+		return nil, nil, fmt.Errorf("invalid handle %d", handle)
+	}
+	net, ok := e.obj.(*paragon.Network[float32])
+	if !ok {
+		return nil, nil, fmt.Errorf("not a Network[float32]")
+	}
+	e.lock.Lock()
+	return net, e.lock.Unlock, nil
 }
 
 func cstr(s string) *C.char        { return C.CString(s) }
 func asJSON(v interface{}) *C.char { b, _ := json.Marshal(v); return C.CString(string(b)) }
 func errJSON(msg string) *C.char {
+	return handleErr(0, msg)
+}
+
+// handleErr is errJSON for a failure on a specific handle.
+func handleErr(handle int64, msg string) *C.char {
+	recordError(handle, "error", msg)
 	return asJSON(map[string]string{"error": msg})
 }
 
-// Dynamic parameter conversion (like WASM bridge)
-func convertParameter(param interface{}, expectedType reflect.Type, paramIndex int) (reflect.Value, error) {
-	switch expectedType.Kind() {
-	case reflect.Slice:
-		return convertSlice(param, expectedType, paramIndex)
-	case reflect.Map:
-		return convertMap(param, expectedType, paramIndex)
+// setLastError records a failure for exports that return raw pointers and
+// therefore cannot carry an {"error":...} payload.
+func setLastError(msg string) {
+	setHandleError(0, msg)
+}
 
-	// Integers
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		switch v := param.(type) {
-		case float64:
-			return reflect.ValueOf(int64(v)).Convert(expectedType), nil
-		case float32:
-			return reflect.ValueOf(int64(v)).Convert(expectedType), nil
-		case int, int8, int16, int32, int64:
-			return reflect.ValueOf(v).Convert(expectedType), nil
-		default:
-			return reflect.Value{}, fmt.Errorf("parameter %d: expected int, got %T", paramIndex, param)
-		}
+func setHandleError(handle int64, msg string) {
+	recordError(handle, "last_error", msg)
+	errMu.Lock()
+	defer errMu.Unlock()
+	lastErr = msg
+}
 
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		switch v := param.(type) {
-		case float64:
-			return reflect.ValueOf(uint64(v)).Convert(expectedType), nil
-		case float32:
-			return reflect.ValueOf(uint64(v)).Convert(expectedType), nil
-		case uint, uint8, uint16, uint32, uint64:
-			return reflect.ValueOf(v).Convert(expectedType), nil
-		default:
-			return reflect.Value{}, fmt.Errorf("parameter %d: expected uint, got %T", paramIndex, param)
-		}
+// errorRecord is one Paragon_GetErrorHistory entry. Code is "error" for
+// failures returned as an {"error":...} payload and "last_error" for those
+// reported through Paragon_GetLastError; Handle is 0 when the failure was not
+// tied to a handle.
+type errorRecord struct {
+	Time    time.Time `json:"time"`
+	Code    string    `json:"code"`
+	Export  string    `json:"export"`
+	Message string    `json:"message"`
+	Handle  int64     `json:"handle"`
+}
 
-	case reflect.Float32, reflect.Float64:
-		switch v := param.(type) {
-		case float64:
-			return reflect.ValueOf(v).Convert(expectedType), nil
-		case float32:
-			return reflect.ValueOf(v).Convert(expectedType), nil
-		default:
-			return reflect.Value{}, fmt.Errorf("parameter %d: expected float, got %T", paramIndex, param)
-		}
+// errorHistorySize bounds the ring buffer behind Paragon_GetErrorHistory.
+const errorHistorySize = 64
 
-	case reflect.Bool:
-		if v, ok := param.(bool); ok {
-			return reflect.ValueOf(v), nil
-		}
-		return reflect.Value{}, fmt.Errorf("parameter %d: expected bool, got %T", paramIndex, param)
+var (
+	histMu   sync.Mutex
+	history  [errorHistorySize]errorRecord
+	histNext int
+	histLen  int
+)
 
-	case reflect.String:
-		if v, ok := param.(string); ok {
-			return reflect.ValueOf(v), nil
+func recordError(handle int64, code, msg string) {
+	r := errorRecord{Time: time.Now(), Code: code, Export: callingExport(), Message: msg, Handle: handle}
+	// Handle 0 is never registered; skipping the lookup also keeps errJSON
+	// usable by code that holds mu.
+	if handle != 0 {
+		if e, ok := getEntry(handle); ok {
+			errMu.Lock()
+			e.lastErr, e.lastErrTime = msg, r.Time
+			errMu.Unlock()
 		}
-		return reflect.Value{}, fmt.Errorf("parameter %d: expected string, got %T", paramIndex, param)
+	}
+	histMu.Lock()
+	defer histMu.Unlock()
+	history[histNext] = r
+	histNext = (histNext + 1) % errorHistorySize
+	if histLen < errorHistorySize {
+		histLen++
+	}
+}
 
-	default:
-		// time.Duration special case
-		if expectedType == reflect.TypeOf(time.Duration(0)) {
-			switch v := param.(type) {
-			case float64:
-				return reflect.ValueOf(time.Duration(v)), nil
-			case float32:
-				return reflect.ValueOf(time.Duration(v)), nil
-			}
-			return reflect.Value{}, fmt.Errorf("parameter %d: expected duration number, got %T", paramIndex, param)
+// callingExport names the outermost Paragon_* export on the current stack
+// (the one the host called, when one export delegates to another), or "" if
+// the error came from elsewhere.
+func callingExport() string {
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	export := ""
+	for {
+		f, more := frames.Next()
+		if i := strings.LastIndex(f.Function, ".Paragon_"); i >= 0 {
+			export = f.Function[i+1:]
 		}
-		// struct via map → JSON → struct
-		if expectedType.Kind() == reflect.Struct {
-			if m, ok := param.(map[string]interface{}); ok {
-				b, _ := json.Marshal(m)
-				holder := reflect.New(expectedType).Interface()
-				if err := json.Unmarshal(b, holder); err != nil {
-					return reflect.Value{}, fmt.Errorf("parameter %d: struct decode: %v", paramIndex, err)
-				}
-				return reflect.ValueOf(holder).Elem(), nil
-			}
+		if !more {
+			return export
 		}
-		return reflect.Zero(expectedType), fmt.Errorf("parameter %d: unsupported type %s", paramIndex, expectedType.String())
 	}
 }
 
-func convertSlice(param interface{}, expectedType reflect.Type, paramIndex int) (reflect.Value, error) {
-	val, ok := param.([]interface{})
-	if !ok {
-		// Coerce a single number into a 1-length slice
-		if n, ok := param.(float64); ok {
-			s := reflect.MakeSlice(expectedType, 1, 1)
-			elem, err := convertParameter(n, expectedType.Elem(), paramIndex)
-			if err != nil {
-				return reflect.Value{}, err
-			}
-			s.Index(0).Set(elem)
-			return s, nil
-		}
-		return reflect.Value{}, fmt.Errorf("parameter %d: expected slice, got %T", paramIndex, param)
+// checkInputShape is the length check shared by every buffer-taking forward
+// export and Paragon_ValidateInputShape.
+func checkInputShape(net *paragon.Network[float32], length int) error {
+	in := net.Layers[net.InputLayer]
+	if length != in.Width*in.Height {
+		return fmt.Errorf("input shape mismatch: got %d values, input layer %dx%d expects %d",
+			length, in.Width, in.Height, in.Width*in.Height)
 	}
+	return nil
+}
 
-	elemType := expectedType.Elem()
-	out := reflect.MakeSlice(expectedType, len(val), len(val))
-	for i, raw := range val {
-		if elemType.Kind() == reflect.Slice {
-			conv, err := convertSlice(raw, elemType, paramIndex)
-			if err != nil {
-				return reflect.Value{}, err
-			}
-			out.Index(i).Set(conv)
-			continue
-		}
-		if elemType.Kind() == reflect.Struct {
-			if m, ok := raw.(map[string]interface{}); ok {
-				b, _ := json.Marshal(m)
-				holder := reflect.New(elemType).Interface()
-				if err := json.Unmarshal(b, holder); err != nil {
-					return reflect.Value{}, fmt.Errorf("parameter %d: struct element decode: %v", paramIndex, err)
-				}
-				out.Index(i).Set(reflect.ValueOf(holder).Elem())
-				continue
-			}
-			return reflect.Value{}, fmt.Errorf("parameter %d: invalid struct element %T", paramIndex, raw)
-		}
-		elem, err := convertParameter(raw, elemType, paramIndex)
-		if err != nil {
-			return reflect.Value{}, err
+// targetFromC reshapes a flat float buffer into the network's output grid.
+func targetFromC(net *paragon.Network[float32], data *C.float, length C.int) ([][]float64, error) {
+	out := net.Layers[net.OutputLayer]
+	if int(length) != out.Width*out.Height {
+		return nil, fmt.Errorf("target shape mismatch: got %d values, output layer %dx%d expects %d",
+			int(length), out.Width, out.Height, out.Width*out.Height)
+	}
+	if data == nil {
+		return nil, fmt.Errorf("target buffer is NULL")
+	}
+
+	src := unsafe.Slice((*float32)(unsafe.Pointer(data)), int(length))
+	grid := make([][]float64, out.Height)
+	for y := range grid {
+		grid[y] = make([]float64, out.Width)
+		for x := range grid[y] {
+			grid[y][x] = float64(src[y*out.Width+x])
 		}
-		out.Index(i).Set(elem)
 	}
-	return out, nil
+	return grid, nil
 }
 
-func convertMap(param interface{}, expectedType reflect.Type, paramIndex int) (reflect.Value, error) {
-	jm, ok := param.(map[string]interface{})
-	if !ok {
-		return reflect.Value{}, fmt.Errorf("parameter %d: expected map, got %T", paramIndex, param)
+// inputFromC reshapes a flat float buffer into the network's input grid.
+func inputFromC(net *paragon.Network[float32], data *C.float, length C.int) ([][]float64, error) {
+	in := net.Layers[net.InputLayer]
+	if err := checkInputShape(net, int(length)); err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, fmt.Errorf("input buffer is NULL")
 	}
-	keyT := expectedType.Key()
-	valT := expectedType.Elem()
-	out := reflect.MakeMap(expectedType)
 
-	for keyStr, raw := range jm {
-		var keyV reflect.Value
-		switch keyT.Kind() {
-		case reflect.String:
-			keyV = reflect.ValueOf(keyStr)
-		case reflect.Int:
-			i, err := strconv.Atoi(keyStr)
-			if err != nil {
-				return reflect.Value{}, fmt.Errorf("parameter %d: bad map key %q", paramIndex, keyStr)
-			}
-			keyV = reflect.ValueOf(i)
-		default:
-			return reflect.Value{}, fmt.Errorf("parameter %d: unsupported map key type %s", paramIndex, keyT)
+	src := unsafe.Slice((*float32)(unsafe.Pointer(data)), int(length))
+	grid := make([][]float64, in.Height)
+	for y := 0; y < in.Height; y++ {
+		grid[y] = make([]float64, in.Width)
+		for x := 0; x < in.Width; x++ {
+			grid[y][x] = float64(src[y*in.Width+x])
 		}
-		valV, err := convertParameter(raw, valT, paramIndex)
-		if err != nil {
-			return reflect.Value{}, err
-		}
-		out.SetMapIndex(keyV, valV)
 	}
-	return out, nil
+	return grid, nil
 }
 
-// Dynamic method calling with JSON arguments
-func callMethodWithJSON(target reflect.Value, argsJSON string) *C.char {
-	mt := target.Type()
-	want := mt.NumIn()
+// floatBuf copies values into a C-allocated float buffer the caller frees
+// with Paragon_FreeFloatBuffer.
+func floatBuf(vals []float64) *C.float {
+	n := len(vals)
+	if n == 0 {
+		n = 1 // malloc(0) may return NULL
+	}
+	p := (*C.float)(C.malloc(C.size_t(n) * C.size_t(unsafe.Sizeof(C.float(0)))))
+	dst := unsafe.Slice((*float32)(unsafe.Pointer(p)), n)
+	for i, v := range vals {
+		dst[i] = float32(v)
+	}
+	return p
+}
 
-	// Parse argsJSON as array of parameters
-	var params []interface{}
-	if argsJSON == "" || argsJSON == "[]" {
-		params = nil
-	} else if err := json.Unmarshal([]byte(argsJSON), &params); err != nil {
-		// If not an array, try single element
-		var single interface{}
-		if err2 := json.Unmarshal([]byte(argsJSON), &single); err2 != nil {
-			return errJSON("Invalid JSON input: " + err.Error())
+// activationParams lists the tunable parameters of each parametric
+// activation with paragon's built-in values as defaults.
+var activationParams = map[string]map[string]float64{
+	"leaky_relu": {"alpha": 0.01},
+	"elu":        {"alpha": 1.0},
+}
+
+// customActivations maps names registered with Paragon_RegisterActivation to
+// their paragon_activation_cb.
+var (
+	customMu          sync.RWMutex
+	customActivations = map[string]uintptr{}
+)
+
+func customActivation(name string) (uintptr, bool) {
+	customMu.RLock()
+	defer customMu.RUnlock()
+	cb, ok := customActivations[name]
+	return cb, ok
+}
+
+// knownActivation reports whether name is built in or registered.
+func knownActivation(name string) bool {
+	_, ok := customActivation(name)
+	return ok || activations[name]
+}
+
+// usesCustomActivation reports whether any layer of net uses a registered
+// activation, which only forwardCPU can apply.
+func usesCustomActivation(net *paragon.Network[float32]) bool {
+	customMu.RLock()
+	defer customMu.RUnlock()
+	if len(customActivations) == 0 {
+		return false
+	}
+	for _, layer := range net.Layers {
+		if _, ok := customActivations[layer.Neurons[0][0].Activation]; ok {
+			return true
 		}
-		params = []interface{}{single}
 	}
+	return false
+}
 
-	if len(params) != want {
-		return errJSON(fmt.Sprintf("Expected %d parameters, got %d", want, len(params)))
+// applyCustom runs a registered activation over a layer's pre-activations.
+func applyCustom(cb uintptr, zs []float32) []float32 {
+	out := make([]float32, len(zs))
+	if len(zs) > 0 {
+		C.call_activation_cb(C.uintptr_t(cb), (*C.float)(unsafe.Pointer(&zs[0])), (*C.float)(unsafe.Pointer(&out[0])), C.int(len(zs)))
 	}
+	return out
+}
 
-	in := make([]reflect.Value, want)
-	for i := 0; i < want; i++ {
-		exp := mt.In(i)
-		val, err := convertParameter(params[i], exp, i)
-		if err != nil {
-			return errJSON(err.Error())
+// customDerivative estimates a registered activation's derivative at each of
+// zs by central differences, in two callbacks for the whole layer.
+func customDerivative(cb uintptr, zs []float32) []float64 {
+	lo, hi := make([]float32, len(zs)), make([]float32, len(zs))
+	steps := make([]float64, len(zs))
+	for i, z := range zs {
+		h := float32(1e-3 * math.Max(1, math.Abs(float64(z))))
+		lo[i], hi[i] = z-h, z+h
+		steps[i] = float64(hi[i] - lo[i])
+	}
+	flo, fhi := applyCustom(cb, lo), applyCustom(cb, hi)
+	d := make([]float64, len(zs))
+	for i := range zs {
+		d[i] = float64(fhi[i]-flo[i]) / steps[i]
+	}
+	return d
+}
+
+// preActivation is a neuron's weighted input plus bias.
+func preActivation(net *paragon.Network[float32], neuron *paragon.Neuron[float32]) float32 {
+	sum := neuron.Bias
+	for _, c := range neuron.Inputs {
+		sum += net.Layers[c.SourceLayer].Neurons[c.SourceY][c.SourceX].Value * c.Weight
+	}
+	return sum
+}
+
+// activate applies an activation, honoring per-layer parameters that
+// paragon's own ApplyActivationGeneric cannot take.
+func activate(x float32, act string, params map[string]float64) float32 {
+	if alpha, ok := params["alpha"]; ok {
+		switch act {
+		case "leaky_relu":
+			if x > 0 {
+				return x
+			}
+			return float32(alpha) * x
+		case "elu":
+			if x >= 0 {
+				return x
+			}
+			return float32(alpha * (math.Exp(float64(x)) - 1))
 		}
-		in[i] = val
 	}
+	return paragon.ApplyActivationGeneric(x, act)
+}
 
-	defer func() {
-		if r := recover(); r != nil {
-			// Handle panics gracefully
+// runForward is the forward pass behind the bridge's forward exports. It is
+// paragon's own Forward unless the handle carries bridge-side activation
+// parameters or registered activations, which only forwardCPU applies.
+func runForward(e *entry, net *paragon.Network[float32], input [][]float64) {
+	e.forwards.Add(1)
+	recordInput(e, input)
+	if e.calib != nil && e.calib.active {
+		forwardCPU(net, e.actParams, input, nil)
+		e.calib.observe(net)
+		return
+	}
+	if len(e.actParams) > 0 || usesCustomActivation(net) {
+		forwardCPU(net, e.actParams, input, nil)
+		return
+	}
+	if net.WebGPUNative {
+		defer timeGPU(e)()
+	}
+	net.Forward(input)
+}
+
+// timeGPU starts timing a GPU forward pass on e; call the result when it ends.
+func timeGPU(e *entry) func() {
+	start := time.Now()
+	return func() { e.gpuBusy.Add(int64(time.Since(start))) }
+}
+
+// calibration tracks the smallest and largest activation seen in each layer
+// over the forward passes run while active.
+type calibration struct {
+	active   bool
+	samples  int
+	min, max []float64
+}
+
+func (c *calibration) observe(net *paragon.Network[float32]) {
+	if c.samples == 0 {
+		c.min = make([]float64, len(net.Layers))
+		c.max = make([]float64, len(net.Layers))
+	}
+	for l, layer := range net.Layers {
+		for y, row := range layer.Neurons {
+			for x, neuron := range row {
+				v := float64(neuron.Value)
+				if c.samples == 0 && x == 0 && y == 0 {
+					c.min[l], c.max[l] = v, v
+				}
+				c.min[l] = math.Min(c.min[l], v)
+				c.max[l] = math.Max(c.max[l], v)
+			}
 		}
-	}()
+	}
+	c.samples++
+}
 
-	out := target.Call(in)
-	res := make([]interface{}, len(out))
+// scoreHeap is a min-heap of output indices ordered by score (ties broken
+// toward the higher index, so lower indices survive), used to keep the k
+// best outputs without sorting all of them.
+type scoreHeap struct {
+	idx  []int
+	vals []float64
+}
+
+func (h scoreHeap) Len() int { return len(h.idx) }
+func (h scoreHeap) Less(i, j int) bool {
+	a, b := h.vals[h.idx[i]], h.vals[h.idx[j]]
+	return a < b || (a == b && h.idx[i] > h.idx[j])
+}
+func (h scoreHeap) Swap(i, j int)       { h.idx[i], h.idx[j] = h.idx[j], h.idx[i] }
+func (h *scoreHeap) Push(x interface{}) { h.idx = append(h.idx, x.(int)) }
+func (h *scoreHeap) Pop() interface{} {
+	x := h.idx[len(h.idx)-1]
+	h.idx = h.idx[:len(h.idx)-1]
+	return x
+}
+
+// topK returns the indices of the k largest values, best first.
+func topK(vals []float64, k int) []int {
+	h := &scoreHeap{idx: make([]int, 0, k+1), vals: vals}
+	for i := range vals {
+		heap.Push(h, i)
+		if h.Len() > k {
+			heap.Pop(h)
+		}
+	}
+	out := make([]int, h.Len())
+	for i := len(out) - 1; i >= 0; i-- {
+		out[i] = heap.Pop(h).(int)
+	}
+	return out
+}
+
+// softmaxTemperature returns softmax(logits / t), shifted by the max logit
+// for stability.
+func softmaxTemperature(logits []float64, t float64) []float64 {
+	maxV := math.Inf(-1)
+	for _, v := range logits {
+		maxV = math.Max(maxV, v)
+	}
+	out := make([]float64, len(logits))
+	var sum float64
+	for i, v := range logits {
+		out[i] = math.Exp((v - maxV) / t)
+		sum += out[i]
+	}
 	for i := range out {
-		res[i] = out[i].Interface()
+		out[i] /= sum
 	}
-	return asJSON(res)
+	return out
 }
 
-// Dynamic method wrapper for any object
-func wrapObjectMethods(obj interface{}) map[string]*C.char {
-	methods := make(map[string]*C.char)
-	val := reflect.ValueOf(obj)
-	typ := val.Type()
+// rngBackends are the generators Paragon_SetRandomBackend can select for the
+// bridge's seeded exports. "go" is math/rand's own source, the default.
+var rngBackends = map[string]func(seed int64) rand.Source{
+	"go":      func(seed int64) rand.Source { return rand.NewSource(seed) },
+	"pcg":     func(seed int64) rand.Source { return pcgSource{randv2.NewPCG(uint64(seed), 0x9e3779b97f4a7c15)} },
+	"mt19937": func(seed int64) rand.Source { return newMT19937(uint32(seed)) },
+}
 
-	for i := 0; i < typ.NumMethod(); i++ {
-		method := typ.Method(i)
-		// Only export public methods
-		if method.Name[0] >= 'A' && method.Name[0] <= 'Z' {
-			methodName := method.Name
-			methodValue := val.Method(i)
+var (
+	rngMu      sync.Mutex
+	rngBackend = "go"
 
-			// Create a closure for each method
-			methods[methodName] = asJSON(map[string]interface{}{
-				"name": methodName,
-				"type": methodValue.Type().String(),
-			})
+	// Set by Paragon_SetGlobalSeed; networkSeeds counts networks built since.
+	globalSeed   int64
+	globalSeeded bool
+	networkSeeds int64
+)
+
+// nextNetworkSeed returns the initialization seed for the next network
+// constructed, if a global seed is set: the global seed plus the number of
+// networks built since it was set, so each network differs but a session is
+// reproducible.
+func nextNetworkSeed() (int64, bool) {
+	rngMu.Lock()
+	defer rngMu.Unlock()
+	if !globalSeeded {
+		return 0, false
+	}
+	seed := globalSeed + networkSeeds
+	networkSeeds++
+	return seed, true
+}
+
+// newRNG returns a generator of the selected backend seeded with seed.
+func newRNG(seed int64) *rand.Rand {
+	rngMu.Lock()
+	mk := rngBackends[rngBackend]
+	rngMu.Unlock()
+	return rand.New(mk(seed))
+}
+
+// pcgSource adapts math/rand/v2's PCG-DXSM to a math/rand Source.
+type pcgSource struct{ *randv2.PCG }
+
+func (p pcgSource) Int63() int64    { return int64(p.Uint64() >> 1) }
+func (p pcgSource) Seed(seed int64) { p.PCG.Seed(uint64(seed), 0x9e3779b97f4a7c15) }
+
+// mt19937 is the 32-bit Mersenne Twister (Matsumoto & Nishimura, with the
+// 2002 init_genrand seeding), matching std::mt19937 and numpy's MT19937
+// output stream.
+type mt19937 struct {
+	mt  [624]uint32
+	idx int
+}
+
+func newMT19937(seed uint32) *mt19937 {
+	m := &mt19937{}
+	m.Seed(int64(seed))
+	return m
+}
+
+func (m *mt19937) Seed(seed int64) {
+	m.mt[0] = uint32(seed)
+	for i := 1; i < len(m.mt); i++ {
+		m.mt[i] = 1812433253*(m.mt[i-1]^(m.mt[i-1]>>30)) + uint32(i)
+	}
+	m.idx = len(m.mt)
+}
+
+func (m *mt19937) uint32() uint32 {
+	if m.idx >= len(m.mt) {
+		for i := range m.mt {
+			y := m.mt[i]&0x80000000 | m.mt[(i+1)%624]&0x7fffffff
+			next := m.mt[(i+397)%624] ^ y>>1
+			if y&1 != 0 {
+				next ^= 0x9908b0df
+			}
+			m.mt[i] = next
 		}
+		m.idx = 0
 	}
-	return methods
+	y := m.mt[m.idx]
+	m.idx++
+	y ^= y >> 11
+	y ^= y << 7 & 0x9d2c5680
+	y ^= y << 15 & 0xefc60000
+	return y ^ y>>18
+}
+
+func (m *mt19937) Int63() int64 {
+	return int64(uint64(m.uint32())<<31 | uint64(m.uint32())>>1)
+}
+
+// recordInput keeps a copy of a forward pass's input for
+// Paragon_GetLastForwardInput.
+func recordInput(e *entry, input [][]float64) {
+	flat := e.lastInput[:0]
+	for _, row := range input {
+		flat = append(flat, row...)
+	}
+	e.lastInput = flat
+}
+
+// inputGrid reshapes a flat input (as kept in entry.lastInput) into the
+// network's input grid.
+func inputGrid(net *paragon.Network[float32], flat []float64) [][]float64 {
+	in := net.Layers[net.InputLayer]
+	grid := make([][]float64, in.Height)
+	for y := range grid {
+		grid[y] = flat[y*in.Width : (y+1)*in.Width]
+	}
+	return grid
+}
+
+// forwardCPU mirrors paragon's dense CPU forward pass using the exported
+// neuron graph, so the bridge can observe or alter each layer's activations
+// through afterLayer before the next layer reads them. params holds optional
+// per-layer activation parameters, and a layer whose first neuron uses a
+// registered activation is passed to its callback in one call. Layer replay
+// is not applied and the GPU path is never used.
+func forwardCPU(net *paragon.Network[float32], params map[int]map[string]float64, input [][]float64, afterLayer func(l int)) {
+	forwardCPUTo(net, params, input, net.OutputLayer, afterLayer)
+}
+
+// forwardCPUTo is forwardCPU stopping after layer last; layers beyond it keep
+// their previous values.
+func forwardCPUTo(net *paragon.Network[float32], params map[int]map[string]float64, input [][]float64, last int, afterLayer func(l int)) {
+	in := net.Layers[net.InputLayer]
+	for y := 0; y < in.Height; y++ {
+		for x := 0; x < in.Width; x++ {
+			in.Neurons[y][x].Value = float32(input[y][x])
+		}
+	}
+
+	for l := net.InputLayer + 1; l <= last; l++ {
+		layer := net.Layers[l]
+		if cb, ok := customActivation(layer.Neurons[0][0].Activation); ok {
+			zs := make([]float32, 0, layer.Width*layer.Height)
+			for _, row := range layer.Neurons {
+				for _, neuron := range row {
+					zs = append(zs, preActivation(net, neuron))
+				}
+			}
+			vals := applyCustom(cb, zs)
+			for y, row := range layer.Neurons {
+				for x, neuron := range row {
+					neuron.Value = vals[y*layer.Width+x]
+				}
+			}
+		} else {
+			for _, row := range layer.Neurons {
+				for _, neuron := range row {
+					neuron.Value = activate(preActivation(net, neuron), neuron.Activation, params[l])
+				}
+			}
+		}
+		if afterLayer != nil {
+			afterLayer(l)
+		}
+	}
+
+	if last == net.OutputLayer && net.Layers[last].Neurons[0][0].Activation == "softmax" {
+		net.ApplySoftmax()
+	}
+}
+
+// flatWeights returns the bridge's flat parameter layout shared by every
+// weight export/import: for each layer after the input, every neuron's
+// incoming weights (neurons row-major, connections in stored order) followed
+// by that layer's biases in the same neuron order.
+func flatWeights(net *paragon.Network[float32]) []float64 {
+	vals := make([]float64, 0, paramCount(net))
+	for l := net.InputLayer + 1; l < len(net.Layers); l++ {
+		layer := net.Layers[l]
+		for _, row := range layer.Neurons {
+			for _, neuron := range row {
+				for _, c := range neuron.Inputs {
+					vals = append(vals, float64(c.Weight))
+				}
+			}
+		}
+		for _, row := range layer.Neurons {
+			for _, neuron := range row {
+				vals = append(vals, float64(neuron.Bias))
+			}
+		}
+	}
+	return vals
+}
+
+// loadFlatWeights is the inverse of flatWeights.
+func loadFlatWeights(net *paragon.Network[float32], vals []float32) error {
+	if want := paramCount(net); len(vals) != want {
+		return fmt.Errorf("weight count mismatch: network has %d parameters, got %d", want, len(vals))
+	}
+	i := 0
+	for l := net.InputLayer + 1; l < len(net.Layers); l++ {
+		layer := net.Layers[l]
+		for _, row := range layer.Neurons {
+			for _, neuron := range row {
+				for k := range neuron.Inputs {
+					neuron.Inputs[k].Weight = vals[i]
+					i++
+				}
+			}
+		}
+		for _, row := range layer.Neurons {
+			for _, neuron := range row {
+				neuron.Bias = vals[i]
+				i++
+			}
+		}
+	}
+	return nil
+}
+
+// activationDerivative is d activate(z)/dz given the pre-activation z and the
+// output y, for the functions paragon actually computes: its tanh is a
+// piecewise approximation (identity below 0.25, flat beyond 1) and is
+// differentiated as such. Softmax outputs use 1, which combined with a
+// softmax output layer yields the cross-entropy error.
+func activationDerivative(z, y float32, act string, params map[string]float64) float64 {
+	alpha := activationParams[act]["alpha"]
+	if a, ok := params["alpha"]; ok {
+		alpha = a
+	}
+	switch act {
+	case "relu":
+		if z > 0 {
+			return 1
+		}
+		return 0
+	case "sigmoid":
+		return float64(y) * (1 - float64(y))
+	case "tanh":
+		a := math.Abs(float64(z))
+		switch {
+		case a > 1:
+			return 0
+		case a < 0.25:
+			return 1
+		default:
+			return 4 / ((1 + 2*a) * (1 + 2*a))
+		}
+	case "leaky_relu":
+		if z > 0 {
+			return 1
+		}
+		return alpha
+	case "elu":
+		if z >= 0 {
+			return 1
+		}
+		return alpha * math.Exp(float64(z))
+	default:
+		return 1
+	}
+}
+
+// trainLoss is the loss the training exports minimize: the named entry of
+// lossFunctions, or with name "" (the default) cross-entropy for a softmax
+// output layer and half the squared error otherwise.
+func trainLoss(net *paragon.Network[float32], targets [][]float64, name string) float64 {
+	out := net.Layers[net.OutputLayer]
+	if name != "" {
+		return lossFunctions[name](flatOutput(net), flatGrid(targets))
+	}
+	if out.Neurons[0][0].Activation == "softmax" {
+		return net.ComputeLoss(targets)
+	}
+	var loss float64
+	for y, row := range out.Neurons {
+		for x, neuron := range row {
+			d := float64(neuron.Value) - targets[y][x]
+			loss += 0.5 * d * d
+		}
+	}
+	return loss
+}
+
+// lossFunctions are the per-sample losses Paragon_ComputeLoss evaluates over
+// the flattened output and target, and that Paragon_SetLossFunction can
+// select for training.
+var lossFunctions = map[string]func(pred, target []float64) float64{
+	"mse": func(pred, target []float64) float64 {
+		var sum float64
+		for i, p := range pred {
+			d := p - target[i]
+			sum += d * d
+		}
+		return sum / float64(len(pred))
+	},
+	// Same as paragon's ComputeLoss, including its 1e-10 floor.
+	"cross_entropy": func(pred, target []float64) float64 {
+		var sum float64
+		for i, p := range pred {
+			sum -= target[i] * math.Log(math.Max(p, 1e-10))
+		}
+		return sum
+	},
+	"binary_cross_entropy": func(pred, target []float64) float64 {
+		var sum float64
+		for i, p := range pred {
+			p = clampProb(p)
+			sum -= target[i]*math.Log(p) + (1-target[i])*math.Log(1-p)
+		}
+		return sum / float64(len(pred))
+	},
+	// Huber loss with delta 1.
+	"huber": func(pred, target []float64) float64 {
+		var sum float64
+		for i, p := range pred {
+			d := math.Abs(p - target[i])
+			if d <= 1 {
+				sum += 0.5 * d * d
+			} else {
+				sum += d - 0.5
+			}
+		}
+		return sum / float64(len(pred))
+	},
+}
+
+// lossGradients are the derivatives of lossFunctions with respect to each
+// output value.
+var lossGradients = map[string]func(pred, target []float64) []float64{
+	"mse": func(pred, target []float64) []float64 {
+		g := make([]float64, len(pred))
+		for i, p := range pred {
+			g[i] = 2 * (p - target[i]) / float64(len(pred))
+		}
+		return g
+	},
+	"cross_entropy": func(pred, target []float64) []float64 {
+		g := make([]float64, len(pred))
+		for i, p := range pred {
+			g[i] = -target[i] / math.Max(p, 1e-10)
+		}
+		return g
+	},
+	"binary_cross_entropy": func(pred, target []float64) []float64 {
+		g := make([]float64, len(pred))
+		for i, p := range pred {
+			p = clampProb(p)
+			g[i] = (p - target[i]) / (p * (1 - p)) / float64(len(pred))
+		}
+		return g
+	},
+	"huber": func(pred, target []float64) []float64 {
+		g := make([]float64, len(pred))
+		for i, p := range pred {
+			g[i] = math.Max(-1, math.Min(1, p-target[i])) / float64(len(pred))
+		}
+		return g
+	},
+}
+
+// clampProb keeps a probability away from 0 and 1 so binary cross-entropy
+// and its gradient stay finite.
+func clampProb(p float64) float64 {
+	return math.Max(1e-7, math.Min(1-1e-7, p))
+}
+
+// flatOutput is the output layer's values in row-major order.
+func flatOutput(net *paragon.Network[float32]) []float64 {
+	var vals []float64
+	for _, row := range net.Layers[net.OutputLayer].Neurons {
+		for _, neuron := range row {
+			vals = append(vals, float64(neuron.Value))
+		}
+	}
+	return vals
+}
+
+func flatGrid(grid [][]float64) []float64 {
+	var flat []float64
+	for _, row := range grid {
+		flat = append(flat, row...)
+	}
+	return flat
+}
+
+// lossDelta is dL/d(output) of trainLoss, the starting point of
+// backpropagate for training. backpropagate takes a softmax layer's
+// derivative as 1, so for a softmax output a named loss's gradient is carried
+// through the softmax Jacobian here; the default loss's output - target
+// already is the cross-entropy gradient at the logits.
+func lossDelta(net *paragon.Network[float32], targets [][]float64, name string) [][]float64 {
+	out := net.Layers[net.OutputLayer]
+	var g []float64
+	if name != "" {
+		pred := flatOutput(net)
+		g = lossGradients[name](pred, flatGrid(targets))
+		if out.Neurons[0][0].Activation == "softmax" {
+			var dot float64
+			for i, p := range pred {
+				dot += g[i] * p
+			}
+			for i, p := range pred {
+				g[i] = p * (g[i] - dot)
+			}
+		}
+	}
+
+	delta := make([][]float64, out.Height)
+	for y, row := range out.Neurons {
+		delta[y] = make([]float64, out.Width)
+		for x, neuron := range row {
+			if g != nil {
+				delta[y][x] = g[y*out.Width+x]
+			} else {
+				delta[y][x] = float64(neuron.Value) - targets[y][x]
+			}
+		}
+	}
+	return delta
+}
+
+// backpropagate carries outDelta, the gradient of some scalar with respect to
+// the output layer's values, back through the activations currently stored in
+// the network (i.e. right after forwardCPU on the matching input). It returns
+// the gradient with respect to every parameter, in the flatWeights layout,
+// and with respect to each input value.
+func backpropagate(net *paragon.Network[float32], params map[int]map[string]float64, outDelta [][]float64) ([]float64, [][]float64) {
+	// delta[l][y][x] accumulates d/d(output) until layer l is reached, then
+	// becomes d/d(pre-activation).
+	delta := make([][][]float64, len(net.Layers))
+	for l, layer := range net.Layers {
+		delta[l] = make([][]float64, layer.Height)
+		for y := range delta[l] {
+			delta[l][y] = make([]float64, layer.Width)
+		}
+	}
+	for y, row := range outDelta {
+		copy(delta[net.OutputLayer][y], row)
+	}
+
+	// Offsets of each layer's block in the flat layout.
+	offsets := make([]int, len(net.Layers))
+	grad := make([]float64, paramCount(net))
+	off := 0
+	for l := net.InputLayer + 1; l < len(net.Layers); l++ {
+		offsets[l] = off
+		for _, row := range net.Layers[l].Neurons {
+			for _, neuron := range row {
+				off += len(neuron.Inputs) + 1
+			}
+		}
+	}
+
+	for l := len(net.Layers) - 1; l > net.InputLayer; l-- {
+		layer := net.Layers[l]
+		w := offsets[l]
+		b := w
+		for _, row := range layer.Neurons {
+			for _, neuron := range row {
+				b += len(neuron.Inputs)
+			}
+		}
+		var custom []float64
+		if cb, ok := customActivation(layer.Neurons[0][0].Activation); ok {
+			zs := make([]float32, 0, layer.Width*layer.Height)
+			for _, row := range layer.Neurons {
+				for _, neuron := range row {
+					zs = append(zs, preActivation(net, neuron))
+				}
+			}
+			custom = customDerivative(cb, zs)
+		}
+		for y, row := range layer.Neurons {
+			for x, neuron := range row {
+				var d float64
+				if custom != nil {
+					d = delta[l][y][x] * custom[y*layer.Width+x]
+				} else {
+					z := preActivation(net, neuron)
+					d = delta[l][y][x] * activationDerivative(z, neuron.Value, neuron.Activation, params[l])
+				}
+				for _, c := range neuron.Inputs {
+					src := net.Layers[c.SourceLayer].Neurons[c.SourceY][c.SourceX]
+					grad[w] = d * float64(src.Value)
+					w++
+					if c.SourceLayer >= net.InputLayer {
+						delta[c.SourceLayer][c.SourceY][c.SourceX] += d * float64(c.Weight)
+					}
+				}
+				grad[b] = d
+				b++
+			}
+		}
+	}
+	return grad, delta[net.InputLayer]
+}
+
+// clipGradient scales grad in place so its L2 norm is at most maxNorm (no
+// limit if maxNorm is 0) and returns the norms before and after.
+func clipGradient(grad []float64, maxNorm float64) (raw, clipped float64) {
+	var sq float64
+	for _, g := range grad {
+		sq += g * g
+	}
+	raw = math.Sqrt(sq)
+	if maxNorm <= 0 || raw <= maxNorm {
+		return raw, raw
+	}
+	scale := maxNorm / raw
+	for i := range grad {
+		grad[i] *= scale
+	}
+	return raw, maxNorm
+}
+
+// optimizerDefaults lists each supported optimizer's hyperparameters with
+// their default values; no others are accepted.
+var optimizerDefaults = map[string]map[string]float64{
+	"sgd":      {},
+	"momentum": {"momentum": 0.9},
+	"adam":     {"beta1": 0.9, "beta2": 0.999, "epsilon": 1e-8},
+	"rmsprop":  {"decay": 0.9, "epsilon": 1e-8},
+}
+
+// optimizer is a handle's update rule plus its per-parameter state, each
+// slot laid out like flatWeights.
+type optimizer struct {
+	Name  string               `json:"name"`
+	Hyper map[string]float64   `json:"hyperparameters"`
+	Step  int                  `json:"step"`
+	Slots map[string][]float64 `json:"slots"`
+}
+
+// optimizerSlots names the state vectors each optimizer keeps.
+var optimizerSlots = map[string][]string{
+	"sgd":      nil,
+	"momentum": {"velocity"},
+	"adam":     {"m", "v"},
+	"rmsprop":  {"sq"},
+}
+
+func newOptimizer(name string, hyper map[string]float64) (*optimizer, error) {
+	defaults, ok := optimizerDefaults[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown optimizer %q (want sgd, momentum, adam or rmsprop)", name)
+	}
+	o := &optimizer{Name: name, Hyper: map[string]float64{}, Slots: map[string][]float64{}}
+	for k, v := range defaults {
+		o.Hyper[k] = v
+	}
+	for k, v := range hyper {
+		if _, ok := defaults[k]; !ok {
+			return nil, fmt.Errorf("optimizer %q has no hyperparameter %q", name, k)
+		}
+		o.Hyper[k] = v
+	}
+	for k, v := range o.Hyper {
+		switch k {
+		case "epsilon":
+			if !(v > 0) {
+				return nil, fmt.Errorf("epsilon must be > 0, got %v", v)
+			}
+		default:
+			if !(v >= 0 && v < 1) {
+				return nil, fmt.Errorf("%s must be in [0,1), got %v", k, v)
+			}
+		}
+	}
+	return o, nil
+}
+
+// update turns a gradient into the step to subtract from the parameters,
+// advancing the optimizer's state. State whose length no longer matches the
+// network (after an architecture change) starts over from zero.
+func (o *optimizer) update(grad []float64, lr float64) []float64 {
+	for _, name := range optimizerSlots[o.Name] {
+		if len(o.Slots[name]) != len(grad) {
+			o.Slots[name] = make([]float64, len(grad))
+			o.Step = 0
+		}
+	}
+	o.Step++
+
+	step := make([]float64, len(grad))
+	h := o.Hyper
+	switch o.Name {
+	case "momentum":
+		vel := o.Slots["velocity"]
+		for i, g := range grad {
+			vel[i] = h["momentum"]*vel[i] + g
+			step[i] = lr * vel[i]
+		}
+	case "adam":
+		m, v := o.Slots["m"], o.Slots["v"]
+		c1 := 1 - math.Pow(h["beta1"], float64(o.Step))
+		c2 := 1 - math.Pow(h["beta2"], float64(o.Step))
+		for i, g := range grad {
+			m[i] = h["beta1"]*m[i] + (1-h["beta1"])*g
+			v[i] = h["beta2"]*v[i] + (1-h["beta2"])*g*g
+			step[i] = lr * (m[i] / c1) / (math.Sqrt(v[i]/c2) + h["epsilon"])
+		}
+	case "rmsprop":
+		sq := o.Slots["sq"]
+		for i, g := range grad {
+			sq[i] = h["decay"]*sq[i] + (1-h["decay"])*g*g
+			step[i] = lr * g / (math.Sqrt(sq[i]) + h["epsilon"])
+		}
+	default:
+		for i, g := range grad {
+			step[i] = lr * g
+		}
+	}
+	return step
+}
+
+// trainBatch takes one training step on the mean loss of a batch of samples:
+// a CPU forward pass and backpropagation per sample, clipping of the averaged
+// gradient to the handle's cap, weight decay, and an update by the handle's
+// optimizer. It returns the batch's mean loss.
+func trainBatch(e *entry, net *paragon.Network[float32], inputs, targets [][][]float64, lr float64) (float64, error) {
+	var loss float64
+	var grad []float64
+	for i, in := range inputs {
+		var scale []float64
+		var restore func()
+		if e.dropRate > 0 && !e.evalMode {
+			scale, restore = maskWeights(net, e.dropRate, e.dropRNG)
+		}
+		recordInput(e, in)
+		forwardCPU(net, e.actParams, in, nil)
+		loss += trainLoss(net, targets[i], e.loss)
+		g, _ := backpropagate(net, e.actParams, lossDelta(net, targets[i], e.loss))
+		if scale != nil {
+			restore()
+			for k := range g {
+				g[k] *= scale[k]
+			}
+		}
+		if grad == nil {
+			grad = g
+			continue
+		}
+		for k, v := range g {
+			grad[k] += v
+		}
+	}
+	n := float64(len(inputs))
+	for k := range grad {
+		grad[k] /= n
+	}
+
+	zeroFrozen(net, e.frozen, grad)
+	e.rawGradNorm, e.gradNorm = clipGradient(grad, e.gradClip)
+	if e.weightDecay > 0 {
+		addWeightDecay(net, grad, e.weightDecay)
+	}
+	e.trained = true
+	if e.opt == nil {
+		e.opt, _ = newOptimizer("sgd", nil)
+	}
+	// Weight decay and momentum can move a parameter whose loss gradient is
+	// zero, so the step itself is masked too.
+	step := e.opt.update(grad, lr)
+	zeroFrozen(net, e.frozen, step)
+	return loss / n, applyStep(net, step)
+}
+
+// zeroFrozen zeroes the entries of v, in the flatWeights layout, that belong
+// to frozen layers.
+func zeroFrozen(net *paragon.Network[float32], frozen map[int]bool, v []float64) {
+	if len(frozen) == 0 {
+		return
+	}
+	i := 0
+	for l := net.InputLayer + 1; l < len(net.Layers); l++ {
+		n := layerParamCount(net, l)
+		if frozen[l] {
+			clear(v[i : i+n])
+		}
+		i += n
+	}
+}
+
+// layerParamCount is the number of weights and biases of layer l.
+func layerParamCount(net *paragon.Network[float32], l int) int {
+	n := 0
+	for _, row := range net.Layers[l].Neurons {
+		for _, neuron := range row {
+			n += len(neuron.Inputs) + 1
+		}
+	}
+	return n
+}
+
+// addWeightDecay adds the gradient of (lambda/2)·Σw² to grad, in the
+// flatWeights layout, for every connection weight; biases are not decayed.
+func addWeightDecay(net *paragon.Network[float32], grad []float64, lambda float64) {
+	i := 0
+	for l := net.InputLayer + 1; l < len(net.Layers); l++ {
+		layer := net.Layers[l]
+		for _, row := range layer.Neurons {
+			for _, neuron := range row {
+				for _, c := range neuron.Inputs {
+					grad[i] += lambda * float64(c.Weight)
+					i++
+				}
+			}
+		}
+		i += layer.Width * layer.Height
+	}
+}
+
+// maskWeights applies a DropConnect mask to every connection weight: each is
+// zeroed with probability rate and the rest scaled by 1/(1-rate). It returns
+// the factor applied to each parameter in the flatWeights layout (1 for
+// biases), which is also the chain-rule factor from a masked weight's
+// gradient to the original's, and a function that puts the original weights
+// back.
+func maskWeights(net *paragon.Network[float32], rate float64, rng *rand.Rand) ([]float64, func()) {
+	saved := make([]float32, 0, paramCount(net))
+	for _, v := range flatWeights(net) {
+		saved = append(saved, float32(v))
+	}
+	scale := make([]float64, 0, len(saved))
+	keep := 1 / (1 - rate)
+	for l := net.InputLayer + 1; l < len(net.Layers); l++ {
+		layer := net.Layers[l]
+		for _, row := range layer.Neurons {
+			for _, neuron := range row {
+				for k := range neuron.Inputs {
+					f := keep
+					if rng.Float64() < rate {
+						f = 0
+					}
+					neuron.Inputs[k].Weight *= float32(f)
+					scale = append(scale, f)
+				}
+			}
+		}
+		for i := 0; i < layer.Width*layer.Height; i++ {
+			scale = append(scale, 1)
+		}
+	}
+	return scale, func() { loadFlatWeights(net, saved) }
+}
+
+// dataset is a Paragon_NewDataset handle: samples stored flat, reshaped to a
+// network's input and output grids when used.
+type dataset struct {
+	inputs  [][]float64
+	targets [][]float64
+}
+
+// samples reshapes the dataset for net, checking its sample sizes.
+func (d *dataset) samples(net *paragon.Network[float32]) (inputs, targets [][][]float64, err error) {
+	out := net.Layers[net.OutputLayer]
+	if len(d.inputs) > 0 {
+		if err := checkInputShape(net, len(d.inputs[0])); err != nil {
+			return nil, nil, err
+		}
+		if n := len(d.targets[0]); n != out.Width*out.Height {
+			return nil, nil, fmt.Errorf("target shape mismatch: got %d values, output layer %dx%d expects %d",
+				n, out.Width, out.Height, out.Width*out.Height)
+		}
+	}
+	inputs = make([][][]float64, len(d.inputs))
+	targets = make([][][]float64, len(d.targets))
+	for i := range d.inputs {
+		inputs[i] = inputGrid(net, d.inputs[i])
+		targets[i] = make([][]float64, out.Height)
+		for y := range targets[i] {
+			targets[i][y] = d.targets[i][y*out.Width : (y+1)*out.Width]
+		}
+	}
+	return inputs, targets, nil
+}
+
+func getDataset(id int64) (*dataset, error) {
+	obj, ok := get(id)
+	if !ok {
+		return nil, fmt.Errorf("invalid dataset handle %d", id)
+	}
+	d, ok := obj.(*dataset)
+	if !ok {
+		return nil, fmt.Errorf("handle %d is not a dataset", id)
+	}
+	return d, nil
+}
+
+// applyStep subtracts step from every parameter in the flatWeights layout.
+func applyStep(net *paragon.Network[float32], step []float64) error {
+	vals := flatWeights(net)
+	next := make([]float32, len(vals))
+	for i, v := range vals {
+		next[i] = float32(v - step[i])
+	}
+	if err := loadFlatWeights(net, next); err != nil {
+		return err
+	}
+	return syncToGPU(net)
+}
+
+// quantization records how a Network[int8] handle's parameters map back to
+// real values: real = (q - ZeroPoint) * Scale, per layer after the input.
+type quantization struct {
+	Scheme string       `json:"scheme"`
+	Layers []layerQuant `json:"layers"`
+}
+
+type layerQuant struct {
+	Index     int     `json:"index"`
+	Scale     float64 `json:"scale"`
+	ZeroPoint int     `json:"zero_point"`
+}
+
+// quantParams picks the scale and zero point covering [lo, hi]: symmetric
+// maps ±max|v| onto ±127 with a zero point of 0, asymmetric maps [lo, hi]
+// onto the full -128..127 range.
+func quantParams(scheme string, lo, hi float64) (scale float64, zero int) {
+	if scheme == "symmetric" {
+		scale = math.Max(math.Abs(lo), math.Abs(hi)) / 127
+		if scale == 0 {
+			scale = 1
+		}
+		return scale, 0
+	}
+	lo, hi = math.Min(lo, 0), math.Max(hi, 0)
+	scale = (hi - lo) / 255
+	if scale == 0 {
+		scale = 1
+	}
+	zero = int(math.Round(-128 - lo/scale))
+	return scale, max(-128, min(127, zero))
+}
+
+func quantize(v, scale float64, zero int) int8 {
+	q := math.Round(v/scale) + float64(zero)
+	return int8(math.Max(-128, math.Min(127, q)))
+}
+
+// dequantize builds a T network from a quantized one, restoring each
+// parameter as (q - ZeroPoint) * Scale.
+func dequantize[T float32 | float64](src *paragon.Network[int8], q *quantization) (*paragon.Network[T], error) {
+	dst, err := paragon.ConvertNetwork[int8, T](src)
+	if err != nil {
+		return nil, err
+	}
+	for _, lq := range q.Layers {
+		val := func(v int8) T { return T(float64(int(v)-lq.ZeroPoint) * lq.Scale) }
+		for y, row := range src.Layers[lq.Index].Neurons {
+			for x, neuron := range row {
+				d := dst.Layers[lq.Index].Neurons[y][x]
+				d.Bias = val(neuron.Bias)
+				for k, c := range neuron.Inputs {
+					d.Inputs[k].Weight = val(c.Weight)
+				}
+			}
+		}
+	}
+	return dst, nil
+}
+
+// layerWeightShape returns the [neurons x fan-in] shape of a layer's weight
+// matrix. Layers whose neurons have differing fan-in (local connectivity at
+// the borders) have no matrix shape.
+func layerWeightShape[T paragon.Numeric](net *paragon.Network[T], l int) (rows, cols int, err error) {
+	if l <= net.InputLayer || l >= len(net.Layers) {
+		return 0, 0, fmt.Errorf("layer index %d out of range (%d..%d)", l, net.InputLayer+1, len(net.Layers)-1)
+	}
+	layer := net.Layers[l]
+	cols = len(layer.Neurons[0][0].Inputs)
+	for _, row := range layer.Neurons {
+		for _, neuron := range row {
+			if len(neuron.Inputs) != cols {
+				return 0, 0, fmt.Errorf("layer %d has non-uniform fan-in and no matrix shape", l)
+			}
+		}
+	}
+	return layer.Width * layer.Height, cols, nil
+}
+
+// npyDescr is the NumPy dtype string of each network element type the NPY
+// export supports.
+var npyDescr = map[reflect.Type]string{
+	reflect.TypeOf(float32(0)): "<f4",
+	reflect.TypeOf(float64(0)): "<f8",
+	reflect.TypeOf(int8(0)):    "|i1",
+}
+
+// writeNPY writes vals as a version 1.0 .npy file of the given shape.
+func writeNPY[T float32 | float64 | int8](path string, vals []T, shape ...int) error {
+	dims := make([]string, len(shape))
+	for i, d := range shape {
+		dims[i] = strconv.Itoa(d)
+	}
+	tuple := strings.Join(dims, ", ")
+	if len(shape) == 1 {
+		tuple += ","
+	}
+	header := fmt.Sprintf("{'descr': '%s', 'fortran_order': False, 'shape': (%s), }",
+		npyDescr[reflect.TypeOf(*new(T))], tuple)
+	// Magic, version and length take 10 bytes; pad so the data starts
+	// 64-byte aligned, ending the header with a newline.
+	header += strings.Repeat(" ", 63-(10+len(header))%64) + "\n"
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	w.WriteString("\x93NUMPY\x01\x00")
+	binary.Write(w, binary.LittleEndian, uint16(len(header)))
+	w.WriteString(header)
+	binary.Write(w, binary.LittleEndian, vals)
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+var (
+	npyDescrField   = regexp.MustCompile(`'descr':\s*'([^']*)'`)
+	npyFortranField = regexp.MustCompile(`'fortran_order':\s*(True|False)`)
+	npyShapeField   = regexp.MustCompile(`'shape':\s*\(([^)]*)\)`)
+)
+
+// readNPY reads a .npy file written by writeNPY or NumPy, checking that its
+// dtype is T's and its shape is shape.
+func readNPY[T float32 | float64 | int8](path string, shape ...int) ([]T, error) {
+	name := filepath.Base(path)
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) < 10 || string(raw[:6]) != "\x93NUMPY" {
+		return nil, fmt.Errorf("%s: not a .npy file", name)
+	}
+	var hlen, start int
+	switch raw[6] {
+	case 1:
+		hlen, start = int(binary.LittleEndian.Uint16(raw[8:])), 10
+	case 2, 3:
+		if len(raw) < 12 {
+			return nil, fmt.Errorf("%s: truncated header", name)
+		}
+		hlen, start = int(binary.LittleEndian.Uint32(raw[8:])), 12
+	default:
+		return nil, fmt.Errorf("%s: unsupported .npy version %d.%d", name, raw[6], raw[7])
+	}
+	if len(raw) < start+hlen {
+		return nil, fmt.Errorf("%s: truncated header", name)
+	}
+	header := string(raw[start : start+hlen])
+	data := raw[start+hlen:]
+
+	want := npyDescr[reflect.TypeOf(*new(T))]
+	m := npyDescrField.FindStringSubmatch(header)
+	if m == nil {
+		return nil, fmt.Errorf("%s: header has no descr", name)
+	}
+	if m[1] != want {
+		return nil, fmt.Errorf("%s: dtype %s, network expects %s", name, m[1], want)
+	}
+	if m := npyFortranField.FindStringSubmatch(header); m != nil && m[1] == "True" {
+		return nil, fmt.Errorf("%s: fortran_order arrays are not supported", name)
+	}
+	m = npyShapeField.FindStringSubmatch(header)
+	if m == nil {
+		return nil, fmt.Errorf("%s: header has no shape", name)
+	}
+	var got []int
+	for _, d := range strings.Split(m[1], ",") {
+		if d = strings.TrimSpace(d); d == "" {
+			continue
+		}
+		n, err := strconv.Atoi(d)
+		if err != nil {
+			return nil, fmt.Errorf("%s: bad shape (%s)", name, m[1])
+		}
+		got = append(got, n)
+	}
+	if !reflect.DeepEqual(got, shape) {
+		return nil, fmt.Errorf("%s: shape %v, layer expects %v", name, got, shape)
+	}
+
+	n := 1
+	for _, d := range shape {
+		n *= d
+	}
+	vals := make([]T, n)
+	if size := binary.Size(vals); len(data) != size {
+		return nil, fmt.Errorf("%s: %d data bytes, shape %v needs %d", name, len(data), shape, size)
+	}
+	binary.Read(bytes.NewReader(data), binary.LittleEndian, vals)
+	return vals, nil
+}
+
+// importNPY is the inverse of exportNPY. Every file is read and checked
+// before any weight changes.
+func importNPY[T float32 | float64 | int8](net *paragon.Network[T], dir string) ([]string, error) {
+	type layerVals struct{ weights, biases []T }
+	loaded := map[int]layerVals{}
+	var files []string
+	for l := net.InputLayer + 1; l < len(net.Layers); l++ {
+		rows, cols, err := layerWeightShape(net, l)
+		if err != nil {
+			return nil, err
+		}
+		wPath := filepath.Join(dir, fmt.Sprintf("layer%d_weights.npy", l))
+		weights, err := readNPY[T](wPath, rows, cols)
+		if err != nil {
+			return nil, fmt.Errorf("layer %d: %v", l, err)
+		}
+		bPath := filepath.Join(dir, fmt.Sprintf("layer%d_biases.npy", l))
+		biases, err := readNPY[T](bPath, rows)
+		if err != nil {
+			return nil, fmt.Errorf("layer %d: %v", l, err)
+		}
+		loaded[l] = layerVals{weights, biases}
+		files = append(files, wPath, bPath)
+	}
+
+	for l, v := range loaded {
+		w, b := 0, 0
+		for _, row := range net.Layers[l].Neurons {
+			for _, neuron := range row {
+				for k := range neuron.Inputs {
+					neuron.Inputs[k].Weight = v.weights[w]
+					w++
+				}
+				neuron.Bias = v.biases[b]
+				b++
+			}
+		}
+	}
+	return files, nil
+}
+
+// exportNPY writes each layer's weight matrix and bias vector under dir and
+// returns the paths written.
+func exportNPY[T float32 | float64 | int8](net *paragon.Network[T], dir string) ([]string, error) {
+	var files []string
+	for l := net.InputLayer + 1; l < len(net.Layers); l++ {
+		rows, cols, err := layerWeightShape(net, l)
+		if err != nil {
+			return files, err
+		}
+		weights := make([]T, 0, rows*cols)
+		biases := make([]T, 0, rows)
+		for _, row := range net.Layers[l].Neurons {
+			for _, neuron := range row {
+				for _, c := range neuron.Inputs {
+					weights = append(weights, c.Weight)
+				}
+				biases = append(biases, neuron.Bias)
+			}
+		}
+
+		wPath := filepath.Join(dir, fmt.Sprintf("layer%d_weights.npy", l))
+		if err := writeNPY(wPath, weights, rows, cols); err != nil {
+			return files, err
+		}
+		files = append(files, wPath)
+		bPath := filepath.Join(dir, fmt.Sprintf("layer%d_biases.npy", l))
+		if err := writeNPY(bPath, biases, rows); err != nil {
+			return files, err
+		}
+		files = append(files, bPath)
+	}
+	return files, nil
+}
+
+// paramCount is the length of the flatWeights layout.
+func paramCount(net *paragon.Network[float32]) int {
+	n := 0
+	for l := net.InputLayer + 1; l < len(net.Layers); l++ {
+		for _, row := range net.Layers[l].Neurons {
+			for _, neuron := range row {
+				n += len(neuron.Inputs) + 1
+			}
+		}
+	}
+	return n
+}
+
+// activations accepted by paragon's dense forward pass.
+var activations = map[string]bool{
+	"relu": true, "sigmoid": true, "tanh": true, "leaky_relu": true,
+	"elu": true, "linear": true, "softmax": true,
+}
+
+// architecture summarizes each layer's shape and activation.
+func architecture(net *paragon.Network[float32]) []map[string]interface{} {
+	layers := make([]map[string]interface{}, len(net.Layers))
+	for i, layer := range net.Layers {
+		layers[i] = map[string]interface{}{
+			"index":      i,
+			"width":      layer.Width,
+			"height":     layer.Height,
+			"activation": layer.Neurons[0][0].Activation,
+		}
+	}
+	return layers
+}
+
+// gpuBytes mirrors the buffers paragon's optimized GPU path allocates per
+// layer: input, output and staging vectors plus a dense weight matrix and
+// bias vector, all 4-byte elements.
+func gpuBytes(net *paragon.Network[float32]) int64 {
+	if !net.WebGPUNative {
+		return 0
+	}
+	var total int64
+	for l := net.InputLayer + 1; l < len(net.Layers); l++ {
+		in := int64(net.Layers[l-1].Width * net.Layers[l-1].Height)
+		out := int64(net.Layers[l].Width * net.Layers[l].Height)
+		total += 4 * (in + 3*out + in*out)
+	}
+	return total
+}
+
+// syncToGPU re-uploads CPU-side weights after the bridge edits them directly,
+// since the optimized GPU path keeps its own copy.
+func syncToGPU(net *paragon.Network[float32]) error {
+	if !net.WebGPUNative {
+		return nil
+	}
+	net.CleanupOptimizedGPU()
+	if err := net.InitializeOptimizedGPU(); err != nil {
+		net.WebGPUNative = false
+		return fmt.Errorf("failed to re-upload weights to GPU: %v", err)
+	}
+	return nil
+}
+
+// Dynamic parameter conversion (like WASM bridge)
+func convertParameter(param interface{}, expectedType reflect.Type, paramIndex int) (reflect.Value, error) {
+	switch expectedType.Kind() {
+	case reflect.Slice:
+		return convertSlice(param, expectedType, paramIndex)
+	case reflect.Map:
+		return convertMap(param, expectedType, paramIndex)
+
+	// Integers
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		switch v := param.(type) {
+		case float64:
+			return reflect.ValueOf(int64(v)).Convert(expectedType), nil
+		case float32:
+			return reflect.ValueOf(int64(v)).Convert(expectedType), nil
+		case int, int8, int16, int32, int64:
+			return reflect.ValueOf(v).Convert(expectedType), nil
+		default:
+			return reflect.Value{}, fmt.Errorf("parameter %d: expected int, got %T", paramIndex, param)
+		}
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		switch v := param.(type) {
+		case float64:
+			return reflect.ValueOf(uint64(v)).Convert(expectedType), nil
+		case float32:
+			return reflect.ValueOf(uint64(v)).Convert(expectedType), nil
+		case uint, uint8, uint16, uint32, uint64:
+			return reflect.ValueOf(v).Convert(expectedType), nil
+		default:
+			return reflect.Value{}, fmt.Errorf("parameter %d: expected uint, got %T", paramIndex, param)
+		}
+
+	case reflect.Float32, reflect.Float64:
+		switch v := param.(type) {
+		case float64:
+			return reflect.ValueOf(v).Convert(expectedType), nil
+		case float32:
+			return reflect.ValueOf(v).Convert(expectedType), nil
+		default:
+			return reflect.Value{}, fmt.Errorf("parameter %d: expected float, got %T", paramIndex, param)
+		}
+
+	case reflect.Bool:
+		if v, ok := param.(bool); ok {
+			return reflect.ValueOf(v), nil
+		}
+		return reflect.Value{}, fmt.Errorf("parameter %d: expected bool, got %T", paramIndex, param)
+
+	case reflect.String:
+		if v, ok := param.(string); ok {
+			return reflect.ValueOf(v), nil
+		}
+		return reflect.Value{}, fmt.Errorf("parameter %d: expected string, got %T", paramIndex, param)
+
+	default:
+		// time.Duration special case
+		if expectedType == reflect.TypeOf(time.Duration(0)) {
+			switch v := param.(type) {
+			case float64:
+				return reflect.ValueOf(time.Duration(v)), nil
+			case float32:
+				return reflect.ValueOf(time.Duration(v)), nil
+			}
+			return reflect.Value{}, fmt.Errorf("parameter %d: expected duration number, got %T", paramIndex, param)
+		}
+		// struct via map → JSON → struct
+		if expectedType.Kind() == reflect.Struct {
+			if m, ok := param.(map[string]interface{}); ok {
+				b, _ := json.Marshal(m)
+				holder := reflect.New(expectedType).Interface()
+				if err := json.Unmarshal(b, holder); err != nil {
+					return reflect.Value{}, fmt.Errorf("parameter %d: struct decode: %v", paramIndex, err)
+				}
+				return reflect.ValueOf(holder).Elem(), nil
+			}
+		}
+		return reflect.Zero(expectedType), fmt.Errorf("parameter %d: unsupported type %s", paramIndex, expectedType.String())
+	}
+}
+
+func convertSlice(param interface{}, expectedType reflect.Type, paramIndex int) (reflect.Value, error) {
+	val, ok := param.([]interface{})
+	if !ok {
+		// Coerce a single number into a 1-length slice
+		if n, ok := param.(float64); ok {
+			s := reflect.MakeSlice(expectedType, 1, 1)
+			elem, err := convertParameter(n, expectedType.Elem(), paramIndex)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			s.Index(0).Set(elem)
+			return s, nil
+		}
+		return reflect.Value{}, fmt.Errorf("parameter %d: expected slice, got %T", paramIndex, param)
+	}
+
+	elemType := expectedType.Elem()
+	out := reflect.MakeSlice(expectedType, len(val), len(val))
+	for i, raw := range val {
+		if elemType.Kind() == reflect.Slice {
+			conv, err := convertSlice(raw, elemType, paramIndex)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			out.Index(i).Set(conv)
+			continue
+		}
+		if elemType.Kind() == reflect.Struct {
+			if m, ok := raw.(map[string]interface{}); ok {
+				b, _ := json.Marshal(m)
+				holder := reflect.New(elemType).Interface()
+				if err := json.Unmarshal(b, holder); err != nil {
+					return reflect.Value{}, fmt.Errorf("parameter %d: struct element decode: %v", paramIndex, err)
+				}
+				out.Index(i).Set(reflect.ValueOf(holder).Elem())
+				continue
+			}
+			return reflect.Value{}, fmt.Errorf("parameter %d: invalid struct element %T", paramIndex, raw)
+		}
+		elem, err := convertParameter(raw, elemType, paramIndex)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		out.Index(i).Set(elem)
+	}
+	return out, nil
+}
+
+func convertMap(param interface{}, expectedType reflect.Type, paramIndex int) (reflect.Value, error) {
+	jm, ok := param.(map[string]interface{})
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("parameter %d: expected map, got %T", paramIndex, param)
+	}
+	keyT := expectedType.Key()
+	valT := expectedType.Elem()
+	out := reflect.MakeMap(expectedType)
+
+	for keyStr, raw := range jm {
+		var keyV reflect.Value
+		switch keyT.Kind() {
+		case reflect.String:
+			keyV = reflect.ValueOf(keyStr)
+		case reflect.Int:
+			i, err := strconv.Atoi(keyStr)
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("parameter %d: bad map key %q", paramIndex, keyStr)
+			}
+			keyV = reflect.ValueOf(i)
+		default:
+			return reflect.Value{}, fmt.Errorf("parameter %d: unsupported map key type %s", paramIndex, keyT)
+		}
+		valV, err := convertParameter(raw, valT, paramIndex)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		out.SetMapIndex(keyV, valV)
+	}
+	return out, nil
+}
+
+// parseParams decodes argsJSON as an array of parameters, or as a single
+// parameter if it is not an array.
+func parseParams(argsJSON string) ([]interface{}, error) {
+	var params []interface{}
+	if argsJSON == "" || argsJSON == "[]" {
+		return nil, nil
+	}
+	if err := json.Unmarshal([]byte(argsJSON), &params); err != nil {
+		// If not an array, try single element
+		var single interface{}
+		if err2 := json.Unmarshal([]byte(argsJSON), &single); err2 != nil {
+			return nil, fmt.Errorf("Invalid JSON input: %v", err)
+		}
+		params = []interface{}{single}
+	}
+	return params, nil
+}
+
+// convertArgs checks arity and converts parsed parameters to the method's
+// parameter types.
+func convertArgs(mt reflect.Type, params []interface{}) ([]reflect.Value, error) {
+	want := mt.NumIn()
+	if len(params) != want {
+		return nil, fmt.Errorf("Expected %d parameters, got %d", want, len(params))
+	}
+
+	in := make([]reflect.Value, want)
+	for i := 0; i < want; i++ {
+		exp := mt.In(i)
+		val, err := convertParameter(params[i], exp, i)
+		if err != nil {
+			return nil, err
+		}
+		in[i] = val
+	}
+	return in, nil
+}
+
+// invoke calls target, turning a panic inside the method into an error.
+func invoke(target reflect.Value, in []reflect.Value) (out []reflect.Value, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return target.Call(in), nil
+}
+
+// returnedError is the method's trailing error result, if it has one and it
+// is non-nil.
+func returnedError(out []reflect.Value) error {
+	if len(out) == 0 {
+		return nil
+	}
+	if err, ok := out[len(out)-1].Interface().(error); ok && err != nil {
+		return err
+	}
+	return nil
+}
+
+func results(out []reflect.Value) []interface{} {
+	res := make([]interface{}, len(out))
+	for i := range out {
+		res[i] = out[i].Interface()
+	}
+	return res
+}
+
+// Dynamic method calling with JSON arguments
+func callMethodWithJSON(handle int64, target reflect.Value, argsJSON string) *C.char {
+	params, err := parseParams(argsJSON)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	in, err := convertArgs(target.Type(), params)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+
+	out, err := invoke(target, in)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	return asJSON(results(out))
+}
+
+// callWithGPUFallback runs a forward-family method on a GPU network and, if
+// it panics or returns a non-nil error, runs it once more on the CPU. Forward
+// itself never fails: paragon quietly finishes it on the CPU. It is run as
+// ForwardGPUOptimized instead, so a GPU failure is seen and reported.
+func callWithGPUFallback(handle int64, net *paragon.Network[float32], target reflect.Value, name, argsJSON string) *C.char {
+	params, err := parseParams(argsJSON)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	in, err := convertArgs(target.Type(), params)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+
+	var out []reflect.Value
+	var gpuErr error
+	if name == "Forward" {
+		gpuErr = forwardGPUStrict(net, in[0].Interface().([][]float64))
+	} else if out, gpuErr = invoke(target, in); gpuErr == nil {
+		gpuErr = returnedError(out)
+	}
+	if gpuErr == nil {
+		return asJSON(results(out))
+	}
+
+	net.WebGPUNative = false
+	out, err = invoke(target, in)
+	net.WebGPUNative = true
+	if err == nil {
+		err = returnedError(out)
+	}
+	if err != nil {
+		return handleErr(handle, fmt.Sprintf("GPU: %v; CPU retry: %v", gpuErr, err))
+	}
+	return asJSON(map[string]interface{}{
+		"result":           results(out),
+		"fell_back_to_cpu": true,
+		"gpu_error":        gpuErr.Error(),
+	})
+}
+
+// callHook is the paragon_call_hook set by Paragon_SetCallHook, or 0.
+var callHook atomic.Uintptr
+
+// runCallHook invokes the call hook, if any, for one phase of a call.
+func runCallHook(handle int64, name, phase string) {
+	cb := callHook.Load()
+	if cb == 0 {
+		return
+	}
+	cname, cphase := C.CString(name), C.CString(phase)
+	defer C.free(unsafe.Pointer(cname))
+	defer C.free(unsafe.Pointer(cphase))
+	C.call_call_hook(C.uintptr_t(cb), C.int64_t(handle), cname, cphase)
+}
+
+// callEntry is the shared path of Paragon_Call and its variants
+// (Paragon_CallByIndex, Paragon_CallNamed, Paragon_CallWithDefaults,
+// Paragon_CallBatchConcurrent).
+func callEntry(handle int64, e *entry, target reflect.Value, name, argsJSON string) *C.char {
+	runCallHook(handle, name, "before")
+	defer runCallHook(handle, name, "after")
+	defer lockForCall(e, name)()
+	net, isNet := e.obj.(*paragon.Network[float32])
+	if isNet && name == "Forward" && e.calib != nil && e.calib.active {
+		return calibrationForward(handle, e, net, target, argsJSON)
+	}
+	if strings.HasPrefix(name, "Forward") {
+		e.forwards.Add(1)
+		if isNet && net.WebGPUNative {
+			defer timeGPU(e)()
+		}
+	}
+
+	if isNet && e.gpuFallback && net.WebGPUNative && strings.HasPrefix(name, "Forward") {
+		return callWithGPUFallback(handle, net, target, name, argsJSON)
+	}
+	return callMethodWithJSON(handle, target, argsJSON)
+}
+
+// calibrationForward serves a reflected Forward call while calibrating: the
+// input goes through runForward, which runs on the CPU so every layer's
+// activations can be observed. The result is Forward's own (empty) result.
+func calibrationForward(handle int64, e *entry, net *paragon.Network[float32], target reflect.Value, argsJSON string) *C.char {
+	params, err := parseParams(argsJSON)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	in, err := convertArgs(target.Type(), params)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	var flat []float64
+	for _, row := range in[0].Interface().([][]float64) {
+		flat = append(flat, row...)
+	}
+	if err := checkInputShape(net, len(flat)); err != nil {
+		return handleErr(handle, err.Error())
+	}
+	runForward(e, net, inputGrid(net, flat))
+	return asJSON([]interface{}{})
+}
+
+// isPointerReceiver reports whether the named method is only in the method set
+// of the pointer type, i.e. it may mutate the receiver.
+func isPointerReceiver(t reflect.Type, name string) bool {
+	if t.Kind() != reflect.Ptr {
+		return false
+	}
+	_, ok := t.Elem().MethodByName(name)
+	return !ok
+}
+
+// lockForCall takes the handle lock matching the method's receiver kind and
+// returns the matching unlock.
+func lockForCall(e *entry, name string) func() {
+	if isPointerReceiver(reflect.TypeOf(e.obj), name) {
+		e.lock.Lock()
+		return e.lock.Unlock
+	}
+	e.lock.RLock()
+	return e.lock.RUnlock
+}
+
+// callRaw is callEntry for Go-side callers; it frees the C string and
+// returns the JSON payload.
+func callRaw(handle int64, e *entry, target reflect.Value, name, argsJSON string) json.RawMessage {
+	p := callEntry(handle, e, target, name, argsJSON)
+	if p == nil {
+		return json.RawMessage(`{"error":"call panicked"}`)
+	}
+	defer C.free(unsafe.Pointer(p))
+	return json.RawMessage(C.GoString(p))
+}
+
+// Dynamic method wrapper for any object
+func wrapObjectMethods(obj interface{}) map[string]*C.char {
+	methods := make(map[string]*C.char)
+	val := reflect.ValueOf(obj)
+	typ := val.Type()
+
+	for i := 0; i < typ.NumMethod(); i++ {
+		method := typ.Method(i)
+		// Only export public methods
+		if method.Name[0] >= 'A' && method.Name[0] <= 'Z' {
+			methodName := method.Name
+			methodValue := val.Method(i)
+
+			// Create a closure for each method
+			methods[methodName] = asJSON(map[string]interface{}{
+				"name": methodName,
+				"type": methodValue.Type().String(),
+			})
+		}
+	}
+	return methods
+}
+
+// C ABI Exports
+
+//export Paragon_NewNetworkFloat32
+func Paragon_NewNetworkFloat32(
+	layersJSON, activationsJSON, fullyJSON *C.char,
+	useGPU C.bool,
+	debug C.bool,
+) *C.char {
+	return newNetworkFloat32(func(o interface{}) (int64, error) {
+		return put(o), nil
+	}, layersJSON, activationsJSON, fullyJSON, useGPU, debug)
+}
+
+// Paragon_NewNetworkFloat32InNamespace is Paragon_NewNetworkFloat32 with the
+// handle allocated in a namespace from Paragon_NewNamespace. The handle
+// carries its namespace, so every other export takes it unchanged.
+//
+//export Paragon_NewNetworkFloat32InNamespace
+func Paragon_NewNetworkFloat32InNamespace(
+	namespace int64,
+	layersJSON, activationsJSON, fullyJSON *C.char,
+	useGPU C.bool,
+	debug C.bool,
+) *C.char {
+	mu.Lock()
+	_, ok := namespaces[namespace]
+	mu.Unlock()
+	if !ok {
+		return errJSON(fmt.Sprintf("invalid namespace %d", namespace))
+	}
+	return newNetworkFloat32(func(o interface{}) (int64, error) {
+		id, ok := putIn(namespace, o)
+		if !ok {
+			return 0, fmt.Errorf("namespace %d was freed", namespace)
+		}
+		return id, nil
+	}, layersJSON, activationsJSON, fullyJSON, useGPU, debug)
+}
+
+// Paragon_NewNetworkFloat32Reserved is Paragon_NewNetworkFloat32 constructing
+// into an ID reserved with Paragon_ReserveHandles, which it fills; an ID that
+// is not an unfilled reservation is an error and the network is not built.
+//
+//export Paragon_NewNetworkFloat32Reserved
+func Paragon_NewNetworkFloat32Reserved(
+	id int64,
+	layersJSON, activationsJSON, fullyJSON *C.char,
+	useGPU C.bool,
+	debug C.bool,
+) *C.char {
+	mu.Lock()
+	ok := reserved[id]
+	mu.Unlock()
+	if !ok {
+		return errJSON(fmt.Sprintf("handle %d is not reserved", id))
+	}
+	return newNetworkFloat32(func(o interface{}) (int64, error) {
+		if !putReserved(id, o) {
+			return 0, fmt.Errorf("reservation %d was released", id)
+		}
+		return id, nil
+	}, layersJSON, activationsJSON, fullyJSON, useGPU, debug)
+}
+
+// newNetworkFloat32 builds a network and registers it with place.
+func newNetworkFloat32(
+	place func(o interface{}) (int64, error),
+	layersJSON, activationsJSON, fullyJSON *C.char,
+	useGPU C.bool,
+	debug C.bool,
+) *C.char {
+	var layers []struct{ Width, Height int }
+	var acts []string
+	var fully []bool
+
+	if err := json.Unmarshal([]byte(C.GoString(layersJSON)), &layers); err != nil {
+		return errJSON("layers: " + err.Error())
+	}
+	if err := json.Unmarshal([]byte(C.GoString(activationsJSON)), &acts); err != nil {
+		return errJSON("activations: " + err.Error())
+	}
+	if err := json.Unmarshal([]byte(C.GoString(fullyJSON)), &fully); err != nil {
+		return errJSON("fullyConnected: " + err.Error())
+	}
+
+	net, err := paragon.NewNetwork[float32](layers, acts, fully)
+	if err != nil {
+		return errJSON("new network: " + err.Error())
+	}
+	// paragon's own seed argument goes to rand.Seed, a no-op as of Go 1.24,
+	// so a seeded network is redrawn here instead.
+	if seed, ok := nextNetworkSeed(); ok {
+		rng := newRNG(seed)
+		redrawWeights(net, func(_, _ int) float64 { return rng.Float64()*2 - 1 })
+	}
+
+	// Defaults first
+	net.WebGPUNative = false
+	net.Debug = bool(debug)
+
+	var gpuInitOK bool
+	var gpuInitMs int64
+
+	if bool(useGPU) {
+		startGPU := time.Now()
+		net.WebGPUNative = true
+		if err := net.InitializeOptimizedGPU(); err != nil {
+			// Fall back to CPU if init fails
+			net.WebGPUNative = false
+			gpuInitOK = false
+			gpuInitMs = time.Since(startGPU).Milliseconds()
+		} else {
+			gpuInitOK = true
+			gpuInitMs = time.Since(startGPU).Milliseconds()
+			// IMPORTANT: do NOT CleanupOptimizedGPU here—caller owns the handle.
+		}
+	}
+
+	id, err := place(net)
+	if err != nil {
+		net.CleanupOptimizedGPU()
+		return errJSON(err.Error())
+	}
+	return asJSON(map[string]interface{}{
+		"handle":      id,
+		"type":        "Network[float32]",
+		"layers":      len(layers),
+		"gpu":         net.WebGPUNative,
+		"gpu_init_ok": gpuInitOK,
+		"gpu_init_ms": gpuInitMs,
+		"debug":       net.Debug,
+	})
+}
+
+//export Paragon_Call
+func Paragon_Call(handle int64, method *C.char, argsJSON *C.char) *C.char {
+	e, ok := getEntry(handle)
+	if !ok {
+		return handleErr(handle, fmt.Sprintf("invalid handle %d", handle))
+	}
+	touch(handle)
+
+	methodName := C.GoString(method)
+	m := reflect.ValueOf(e.obj).MethodByName(methodName)
+	if !m.IsValid() {
+		return handleErr(handle, "Method not found: "+methodName)
+	}
+
+	return callEntry(handle, e, m, methodName, C.GoString(argsJSON))
+}
+
+// Paragon_SetCallHook registers cb (a paragon_call_hook) to be called with
+// phase "before" and then "after" around every reflected method call made
+// through Paragon_Call and its variants, whether or not the call succeeds.
+// Paragon_CallRepeated reports once for all its iterations, and
+// Paragon_CallBatchConcurrent once per call on the thread running it. The
+// hook only observes: it runs outside the handle's lock on the calling
+// thread, its return is ignored, and the strings it gets are valid only for
+// the duration of the callback. Setting a hook replaces any previous one.
+//
+//export Paragon_SetCallHook
+func Paragon_SetCallHook(cb C.uintptr_t) *C.char {
+	if cb == 0 {
+		return errJSON("callback is NULL; use Paragon_ClearCallHook to remove the hook")
+	}
+	callHook.Store(uintptr(cb))
+	return asJSON(map[string]interface{}{"status": "call hook set"})
+}
+
+// Paragon_ClearCallHook removes the hook set by Paragon_SetCallHook. A call
+// already past its "before" phase may still report "after".
+//
+//export Paragon_ClearCallHook
+func Paragon_ClearCallHook() {
+	callHook.Store(0)
+}
+
+// Paragon_CallBatchConcurrent runs a JSON array of
+// {"handle":ID, "method":"...", "args":[...]} calls and returns
+// {"results":[...]} in input order. Value-receiver (read-only) methods run in
+// parallel; pointer-receiver methods on the same handle run one at a time in
+// the order given. Reads are not ordered relative to writes on the same
+// handle, but never overlap one. Each call otherwise takes the same path as
+// Paragon_Call.
+//
+//export Paragon_CallBatchConcurrent
+func Paragon_CallBatchConcurrent(callsJSON *C.char) *C.char {
+	var calls []struct {
+		Handle int64           `json:"handle"`
+		Method string          `json:"method"`
+		Args   json.RawMessage `json:"args"`
+	}
+	if err := json.Unmarshal([]byte(C.GoString(callsJSON)), &calls); err != nil {
+		return errJSON("calls: " + err.Error())
+	}
+
+	results := make([]json.RawMessage, len(calls))
+	writes := map[int64][]int{}
+	var wg sync.WaitGroup
+
+	for i, c := range calls {
+		e, ok := getEntry(c.Handle)
+		if !ok {
+			results[i], _ = json.Marshal(map[string]string{"error": fmt.Sprintf("invalid handle %d", c.Handle)})
+			continue
+		}
+		m := reflect.ValueOf(e.obj).MethodByName(c.Method)
+		if !m.IsValid() {
+			results[i], _ = json.Marshal(map[string]string{"error": "Method not found: " + c.Method})
+			continue
+		}
+		touch(c.Handle)
+
+		if isPointerReceiver(reflect.TypeOf(e.obj), c.Method) {
+			writes[c.Handle] = append(writes[c.Handle], i)
+			continue
+		}
+		wg.Add(1)
+		go func(i int, e *entry, m reflect.Value, args string) {
+			defer wg.Done()
+			results[i] = callRaw(calls[i].Handle, e, m, calls[i].Method, args)
+		}(i, e, m, string(c.Args))
+	}
+
+	for h, idxs := range writes {
+		e, _ := getEntry(h)
+		wg.Add(1)
+		go func(e *entry, idxs []int) {
+			defer wg.Done()
+			for _, i := range idxs {
+				m := reflect.ValueOf(e.obj).MethodByName(calls[i].Method)
+				results[i] = callRaw(calls[i].Handle, e, m, calls[i].Method, string(calls[i].Args))
+			}
+		}(e, idxs)
+	}
+
+	wg.Wait()
+	return asJSON(map[string]interface{}{
+		"results": results,
+	})
+}
+
+//export Paragon_GetMethodIndex
+func Paragon_GetMethodIndex(handle int64, method *C.char) *C.char {
+	obj, ok := get(handle)
+	if !ok {
+		return handleErr(handle, fmt.Sprintf("invalid handle %d", handle))
+	}
+
+	methodName := C.GoString(method)
+	m, ok := reflect.TypeOf(obj).MethodByName(methodName)
+	if !ok {
+		return handleErr(handle, "Method not found: "+methodName)
+	}
+
+	return asJSON(map[string]interface{}{
+		"name":   methodName,
+		"index":  m.Index,
+		"handle": handle,
+	})
+}
+
+// Paragon_CallByIndex skips the MethodByName lookup of Paragon_Call. Indices
+// come from Paragon_GetMethodIndex and are stable for a given build of the
+// library, since Go orders a type's method set by name.
+//
+//export Paragon_CallByIndex
+func Paragon_CallByIndex(handle int64, methodIndex C.int, argsJSON *C.char) *C.char {
+	e, ok := getEntry(handle)
+	if !ok {
+		return handleErr(handle, fmt.Sprintf("invalid handle %d", handle))
+	}
+	touch(handle)
+
+	val := reflect.ValueOf(e.obj)
+	idx := int(methodIndex)
+	if idx < 0 || idx >= val.NumMethod() {
+		return handleErr(handle, fmt.Sprintf("method index %d out of range for %s (0..%d)", idx, val.Type(), val.NumMethod()-1))
+	}
+
+	return callEntry(handle, e, val.Method(idx), val.Type().Method(idx).Name, C.GoString(argsJSON))
+}
+
+// Paragon_CallRepeated calls method iterations times, passing each call's
+// return values (minus a trailing error) as the next call's positional
+// arguments, so a method returning one value takes that value as its single
+// argument. It returns {"result":[...],"iterations":N}, plus the result of
+// every iteration under "trajectory" when trajectory is true. A returned
+// error stops the loop.
+//
+//export Paragon_CallRepeated
+func Paragon_CallRepeated(handle int64, method *C.char, initialArgsJSON *C.char, iterations C.int, trajectory C.bool) *C.char {
+	e, ok := getEntry(handle)
+	if !ok {
+		return handleErr(handle, fmt.Sprintf("invalid handle %d", handle))
+	}
+	if iterations < 1 {
+		return handleErr(handle, fmt.Sprintf("iterations must be >= 1, got %d", int(iterations)))
+	}
+	touch(handle)
+
+	methodName := C.GoString(method)
+	m := reflect.ValueOf(e.obj).MethodByName(methodName)
+	if !m.IsValid() {
+		return handleErr(handle, "Method not found: "+methodName)
+	}
+	mt := m.Type()
+	returnsErr := mt.NumOut() > 0 && mt.Out(mt.NumOut()-1) == reflect.TypeOf((*error)(nil)).Elem()
+	runCallHook(handle, methodName, "before")
+	defer runCallHook(handle, methodName, "after")
+	defer lockForCall(e, methodName)()
+
+	params, err := parseParams(C.GoString(initialArgsJSON))
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	var res []interface{}
+	var steps [][]interface{}
+	for i := 0; i < int(iterations); i++ {
+		in, err := convertArgs(mt, params)
+		if err != nil {
+			return handleErr(handle, fmt.Sprintf("iteration %d: %v", i, err))
+		}
+		out, err := invoke(m, in)
+		if err == nil {
+			err = returnedError(out)
+		}
+		if err != nil {
+			return handleErr(handle, fmt.Sprintf("iteration %d: %v", i, err))
+		}
+		if returnsErr {
+			out = out[:len(out)-1]
+		}
+		res = results(out)
+		if trajectory {
+			steps = append(steps, res)
+		}
+
+		// Round-trip through JSON so each iteration sees exactly what a host
+		// passing the result back would.
+		b, err := json.Marshal(res)
+		if err != nil {
+			return handleErr(handle, fmt.Sprintf("iteration %d: %v", i, err))
+		}
+		if err := json.Unmarshal(b, &params); err != nil {
+			return handleErr(handle, fmt.Sprintf("iteration %d: %v", i, err))
+		}
+	}
+
+	resp := map[string]interface{}{
+		"result":     res,
+		"iterations": int(iterations),
+	}
+	if trajectory {
+		resp["trajectory"] = steps
+	}
+	return asJSON(resp)
+}
+
+// Paragon_CallNamed is Paragon_Call with arguments given as a JSON object.
+// Go keeps no parameter names at run time, so the only names are positional:
+// p0, p1, ... for the method's parameters in order. Unknown and missing names
+// are errors.
+//
+//export Paragon_CallNamed
+func Paragon_CallNamed(handle int64, method *C.char, namedArgsJSON *C.char) *C.char {
+	e, ok := getEntry(handle)
+	if !ok {
+		return handleErr(handle, fmt.Sprintf("invalid handle %d", handle))
+	}
+	touch(handle)
+
+	methodName := C.GoString(method)
+	m := reflect.ValueOf(e.obj).MethodByName(methodName)
+	if !m.IsValid() {
+		return handleErr(handle, "Method not found: "+methodName)
+	}
+
+	argsJSON, err := positionalArgs(m.Type(), C.GoString(namedArgsJSON))
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	return callEntry(handle, e, m, methodName, argsJSON)
+}
+
+// Paragon_ValidateCall checks argsJSON against a method's signature the way
+// Paragon_Call would (parsing, arity, then convertParameter for each
+// argument) without calling it, and reports every problem found as
+// {"valid":bool,"errors":[{"arg":i,"expected":"type","error":"..."},...]}.
+// Errors not tied to one argument (bad JSON, wrong arity) have no "arg".
+//
+//export Paragon_ValidateCall
+func Paragon_ValidateCall(handle int64, method *C.char, argsJSON *C.char) *C.char {
+	obj, ok := get(handle)
+	if !ok {
+		return handleErr(handle, fmt.Sprintf("invalid handle %d", handle))
+	}
+	methodName := C.GoString(method)
+	m := reflect.ValueOf(obj).MethodByName(methodName)
+	if !m.IsValid() {
+		return handleErr(handle, "Method not found: "+methodName)
+	}
+
+	errs := make([]map[string]interface{}, 0)
+	params, err := parseParams(C.GoString(argsJSON))
+	if err != nil {
+		errs = append(errs, map[string]interface{}{"error": err.Error()})
+	}
+	mt := m.Type()
+	if err == nil && len(params) != mt.NumIn() {
+		errs = append(errs, map[string]interface{}{"error": fmt.Sprintf("Expected %d parameters, got %d", mt.NumIn(), len(params))})
+	}
+	for i := 0; i < len(params) && i < mt.NumIn(); i++ {
+		if _, err := convertParameter(params[i], mt.In(i), i); err != nil {
+			errs = append(errs, map[string]interface{}{
+				"arg":      i,
+				"expected": mt.In(i).String(),
+				"error":    err.Error(),
+			})
+		}
+	}
+	return asJSON(map[string]interface{}{
+		"valid":  len(errs) == 0,
+		"errors": errs,
+	})
+}
+
+// Paragon_CallWithDefaults is Paragon_Call that accepts fewer arguments than
+// the method takes: the missing trailing parameters get their zero values (0,
+// false, "", zero structs, and empty rather than nil slices and maps). Only
+// trailing arguments can be left out, and parameters of other kinds, which
+// Paragon_Call cannot pass either, have no default. Paragon_Call itself
+// stays strict about arity.
+//
+//export Paragon_CallWithDefaults
+func Paragon_CallWithDefaults(handle int64, method *C.char, argsJSON *C.char) *C.char {
+	e, ok := getEntry(handle)
+	if !ok {
+		return handleErr(handle, fmt.Sprintf("invalid handle %d", handle))
+	}
+	touch(handle)
+
+	methodName := C.GoString(method)
+	m := reflect.ValueOf(e.obj).MethodByName(methodName)
+	if !m.IsValid() {
+		return handleErr(handle, "Method not found: "+methodName)
+	}
+
+	args, err := paddedArgs(m.Type(), C.GoString(argsJSON))
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	return callEntry(handle, e, m, methodName, args)
+}
+
+// paddedArgs appends the JSON zero value of each parameter missing from
+// argsJSON.
+func paddedArgs(mt reflect.Type, argsJSON string) (string, error) {
+	params, err := parseParams(argsJSON)
+	if err != nil {
+		return "", err
+	}
+	if len(params) > mt.NumIn() {
+		return "", fmt.Errorf("Expected at most %d parameters, got %d", mt.NumIn(), len(params))
+	}
+	for i := len(params); i < mt.NumIn(); i++ {
+		t := mt.In(i)
+		switch t.Kind() {
+		case reflect.Slice:
+			params = append(params, []interface{}{})
+		case reflect.Map, reflect.Struct:
+			params = append(params, map[string]interface{}{})
+		case reflect.Bool, reflect.String,
+			reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+			params = append(params, reflect.Zero(t).Interface())
+		default:
+			return "", fmt.Errorf("parameter %d: no default for type %s", i, t)
+		}
+	}
+	b, err := json.Marshal(params)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// positionalArgs turns a {"p0":...,"p1":...} object into the JSON array
+// expected by callMethodWithJSON.
+func positionalArgs(mt reflect.Type, namedJSON string) (string, error) {
+	named := map[string]json.RawMessage{}
+	if namedJSON != "" {
+		if err := json.Unmarshal([]byte(namedJSON), &named); err != nil {
+			return "", fmt.Errorf("Invalid JSON input: %v", err)
+		}
+	}
+
+	args := make([]json.RawMessage, mt.NumIn())
+	var missing []string
+	for i := range args {
+		name := fmt.Sprintf("p%d", i)
+		v, ok := named[name]
+		if !ok {
+			missing = append(missing, name)
+			continue
+		}
+		args[i] = v
+		delete(named, name)
+	}
+	if len(named) > 0 {
+		unknown := make([]string, 0, len(named))
+		for name := range named {
+			unknown = append(unknown, name)
+		}
+		sort.Strings(unknown)
+		if mt.NumIn() == 0 {
+			return "", fmt.Errorf("unknown argument names %v (method takes no arguments)", unknown)
+		}
+		return "", fmt.Errorf("unknown argument names %v (expected p0..p%d)", unknown, mt.NumIn()-1)
+	}
+	if len(missing) > 0 {
+		return "", fmt.Errorf("missing arguments %v", missing)
+	}
+
+	b, _ := json.Marshal(args)
+	return string(b), nil
+}
+
+// methodDocs is the documentation registry behind Paragon_GetMethodDoc,
+// keyed by "<Type>.<Method>" with the type's name stripped of its type
+// arguments, so one entry covers Network[float32], Network[int8] and the
+// rest. Entries are one-line summaries adapted from the doc comments in
+// paragon's source (go doc github.com/openfluke/paragon/v3 Network); add one
+// here when binding a method hosts should see described.
+var methodDocs = map[string]string{
+	"Network.AddLayer":               "Inserts a layer of the given size and activation at an index, optionally fully connected to the previous layer.",
+	"Network.AddNeuronsToLayer":      "Adds neurons to a layer.",
+	"Network.ApplySoftmax":           "Normalizes the output layer's values with softmax.",
+	"Network.Backward":               "Backpropagates targets from the last forward pass and updates weights with the given learning rate and clip bounds.",
+	"Network.CleanupOptimizedGPU":    "Releases the network's GPU resources.",
+	"Network.ComputeLoss":            "Returns the cross-entropy loss of the current output against a target grid.",
+	"Network.ComputePerplexity":      "Returns the network's perplexity on a set of inputs and targets.",
+	"Network.ConnectLayers":          "Rebuilds connections between layers from per-layer fully-connected flags.",
+	"Network.ExtractOutput":          "Returns the output layer's values as a slice.",
+	"Network.Forward":                "Runs a forward pass on an input grid, on the GPU when enabled.",
+	"Network.ForwardBatch":           "Runs a forward pass for each input grid and returns every output.",
+	"Network.ForwardFromLayer":       "Runs the forward pass starting from a layer, using the given state for that layer.",
+	"Network.ForwardUntilLayer":      "Runs the forward pass only up to the given layer (inclusive).",
+	"Network.GetLayerState":          "Returns the current values of every neuron in a layer.",
+	"Network.GetOutput":              "Returns the output layer's values as a slice.",
+	"Network.InitializeOptimizedGPU": "Sets up GPU compute for the network.",
+	"Network.LoadJSON":               "Loads topology and weights from a JSON model file written by SaveJSON.",
+	"Network.LoadLayerState":         "Loads a layer's state from a JSON file.",
+	"Network.PadInputToFullSize":     "Pads a partial input with a fill value to the network's full input size.",
+	"Network.PerturbWeights":         "Adds Gaussian noise N(0, rate) to every connection weight.",
+	"Network.ReverseInferFromOutput": "Infers an approximate input from an output layer state.",
+	"Network.SaveJSON":               "Writes the full topology and weights of the network to a JSON file.",
+	"Network.SaveLayerState":         "Saves a layer's state to a JSON file.",
+	"Network.SyncCPUWeightsToGPU":    "Uploads the CPU weights to the GPU.",
+	"Network.SyncGPUWeightsToCPU":    "Copies the GPU weights back to the CPU.",
+	"Network.Train":                  "Runs the training loop over inputs and targets for a number of epochs.",
+	"Network.TrainWithGPUSync":       "Runs the training loop, syncing GPU weights after each epoch.",
+}
+
+// Paragon_GetMethodDoc returns {"method":"...","doc":"..."} for a method of
+// the handle's object, with doc empty when methodDocs has no entry for it.
+//
+//export Paragon_GetMethodDoc
+func Paragon_GetMethodDoc(handle int64, methodName *C.char) *C.char {
+	obj, ok := get(handle)
+	if !ok {
+		return handleErr(handle, fmt.Sprintf("invalid handle %d", handle))
+	}
+	name := C.GoString(methodName)
+	if !reflect.ValueOf(obj).MethodByName(name).IsValid() {
+		return handleErr(handle, "Method not found: "+name)
+	}
+
+	typ := reflect.TypeOf(obj)
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	typeName, _, _ := strings.Cut(typ.Name(), "[")
+	return asJSON(map[string]interface{}{
+		"method": name,
+		"doc":    methodDocs[typeName+"."+name],
+	})
+}
+
+//export Paragon_ListMethods
+func Paragon_ListMethods(handle int64) *C.char {
+	obj, ok := get(handle)
+	if !ok {
+		return handleErr(handle, "invalid handle")
+	}
+
+	val := reflect.ValueOf(obj)
+	typ := val.Type()
+	methods := make([]map[string]interface{}, 0)
+
+	for i := 0; i < typ.NumMethod(); i++ {
+		method := typ.Method(i)
+		if method.Name[0] >= 'A' && method.Name[0] <= 'Z' {
+			methodType := method.Type
+			params := make([]string, methodType.NumIn()-1) // -1 for receiver
+			for j := 1; j < methodType.NumIn(); j++ {
+				params[j-1] = methodType.In(j).String()
+			}
+			returns := make([]string, methodType.NumOut())
+			for j := 0; j < methodType.NumOut(); j++ {
+				returns[j] = methodType.Out(j).String()
+			}
+
+			methods = append(methods, map[string]interface{}{
+				"name":       method.Name,
+				"index":      i,
+				"parameters": params,
+				"returns":    returns,
+			})
+		}
+	}
+
+	return asJSON(map[string]interface{}{
+		"methods": methods,
+		"count":   len(methods),
+	})
+}
+
+// Paragon_EstimateResultSize returns a conservative upper bound, in bytes
+// including the terminating NUL, on the JSON Paragon_Call would return for
+// method on this handle, so hosts copying results into their own buffers can
+// allocate once. It is worked out from the method's return types and the
+// network's dimensions: numeric slices are bounded by the largest layer,
+// while strings, byte slices, structs and maps are bounded by the size of the
+// whole model. Results whose length depends on the arguments (batches,
+// generated text) may exceed it, so it is an estimate only; the length of the
+// string actually returned is authoritative.
+//
+//export Paragon_EstimateResultSize
+func Paragon_EstimateResultSize(handle int64, method *C.char) *C.char {
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	defer unlock()
+	e, _ := getEntry(handle)
+
+	methodName := C.GoString(method)
+	m := reflect.ValueOf(net).MethodByName(methodName)
+	if !m.IsValid() {
+		return handleErr(handle, "Method not found: "+methodName)
+	}
+
+	b := newResultBounds(net)
+	mt := m.Type()
+	returns := make([]string, mt.NumOut())
+	size := 2 // [ ]
+	for i := 0; i < mt.NumOut(); i++ {
+		returns[i] = mt.Out(i).String()
+		size += b.jsonSize(mt.Out(i)) + 1
+	}
+	if e.gpuFallback && strings.HasPrefix(methodName, "Forward") {
+		// {"result":...,"fell_back_to_cpu":true,"gpu_error":"..."}
+		size += 64 + maxErrorBytes
+	}
+	size = max(size, 2+maxErrorBytes) // {"error":"..."}
+
+	return asJSON(map[string]interface{}{
+		"method":         methodName,
+		"returns":        returns,
+		"estimate_bytes": size + 1,
+	})
+}
+
+// maxErrorBytes is the room Paragon_EstimateResultSize leaves for an error
+// message in a result.
+const maxErrorBytes = 1024
+
+// resultBounds holds the network dimensions Paragon_EstimateResultSize bounds
+// result values by.
+type resultBounds struct {
+	maxWidth, maxHeight, maxNeurons int
+	// model bounds anything that can hold the whole network.
+	model int
+}
+
+func newResultBounds(net *paragon.Network[float32]) resultBounds {
+	var b resultBounds
+	neurons := 0
+	for _, layer := range net.Layers {
+		b.maxWidth = max(b.maxWidth, layer.Width)
+		b.maxHeight = max(b.maxHeight, layer.Height)
+		b.maxNeurons = max(b.maxNeurons, layer.Width*layer.Height)
+		neurons += layer.Width * layer.Height
+	}
+	// Serialized models spell out every neuron and connection with its
+	// metadata; 256 bytes apiece covers paragon's JSON format.
+	b.model = 256 * (neurons + paramCount(net))
+	return b
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// jsonSize bounds the JSON encoding of a value of type t.
+func (b resultBounds) jsonSize(t reflect.Type) int {
+	if t == errorType {
+		return 4 // error values encode as {} or null
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return 5
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return 20
+	case reflect.Float32, reflect.Float64:
+		return 24
+	case reflect.Ptr:
+		return b.jsonSize(t.Elem())
+	case reflect.Array:
+		return 2 + t.Len()*(b.jsonSize(t.Elem())+1)
+	case reflect.Slice:
+		elem := t.Elem()
+		switch {
+		case elem.Kind() == reflect.Uint8:
+			return 2 + b.model*4/3 + 4 // base64
+		case elem.Kind() == reflect.Slice && elem.Elem().Kind() != reflect.Uint8:
+			// Layer grids: [height][width]
+			return 2 + b.maxHeight*(2+b.maxWidth*(b.jsonSize(elem.Elem())+1)+1)
+		default:
+			return 2 + b.maxNeurons*(b.jsonSize(elem)+1)
+		}
+	case reflect.String:
+		return 2 + 2*b.model // JSON text with its quotes escaped
+	default: // structs, maps, interfaces
+		return b.model
+	}
+}
+
+//export Paragon_GetInfo
+func Paragon_GetInfo(handle int64) *C.char {
+	obj, ok := get(handle)
+	if !ok {
+		return handleErr(handle, "invalid handle")
+	}
+
+	val := reflect.ValueOf(obj)
+	typ := val.Type()
+
+	info := map[string]interface{}{
+		"type":    typ.String(),
+		"kind":    typ.Kind().String(),
+		"methods": typ.NumMethod(),
+		"handle":  handle,
+	}
+
+	// Add network-specific info if it's a network
+	if net, ok := obj.(*paragon.Network[float32]); ok {
+		info["webgpu_native"] = net.WebGPUNative
+		info["debug"] = net.Debug
+		if e, ok := getEntry(handle); ok {
+			info["mode"] = modeName(e)
+		}
+		// Add more network-specific info as needed
+	}
+	if e, ok := getEntry(handle); ok {
+		info["metadata"] = metadataOf(e)
+	}
+
+	return asJSON(info)
+}
+
+// metadataOf copies the Paragon_SetMetadata tags of e.
+func metadataOf(e *entry) map[string]string {
+	metaMu.Lock()
+	defer metaMu.Unlock()
+	tags := make(map[string]string, len(e.metadata))
+	for k, v := range e.metadata {
+		tags[k] = v
+	}
+	return tags
+}
+
+// Paragon_SetMetadata tags a handle with a string key-value pair, such as an
+// experiment ID or commit hash, replacing any previous value for key. Tags
+// are returned by Paragon_GetMetadata, Paragon_GetAllMetadata and
+// Paragon_GetInfo.
+//
+//export Paragon_SetMetadata
+func Paragon_SetMetadata(handle int64, key, value *C.char) *C.char {
+	e, ok := getEntry(handle)
+	if !ok {
+		return handleErr(handle, fmt.Sprintf("invalid handle %d", handle))
+	}
+	k := C.GoString(key)
+	if k == "" {
+		return handleErr(handle, "metadata key must not be empty")
+	}
+	v := C.GoString(value)
+
+	metaMu.Lock()
+	if e.metadata == nil {
+		e.metadata = map[string]string{}
+	}
+	e.metadata[k] = v
+	metaMu.Unlock()
+	return asJSON(map[string]interface{}{"handle": handle, "key": k, "value": v})
+}
+
+// Paragon_GetMetadata returns one tag set by Paragon_SetMetadata.
+//
+//export Paragon_GetMetadata
+func Paragon_GetMetadata(handle int64, key *C.char) *C.char {
+	e, ok := getEntry(handle)
+	if !ok {
+		return handleErr(handle, fmt.Sprintf("invalid handle %d", handle))
+	}
+	k := C.GoString(key)
+
+	metaMu.Lock()
+	v, found := e.metadata[k]
+	metaMu.Unlock()
+	if !found {
+		return handleErr(handle, fmt.Sprintf("no metadata key %q", k))
+	}
+	return asJSON(map[string]interface{}{"handle": handle, "key": k, "value": v})
+}
+
+// Paragon_GetAllMetadata returns every tag set by Paragon_SetMetadata.
+//
+//export Paragon_GetAllMetadata
+func Paragon_GetAllMetadata(handle int64) *C.char {
+	e, ok := getEntry(handle)
+	if !ok {
+		return handleErr(handle, fmt.Sprintf("invalid handle %d", handle))
+	}
+	return asJSON(map[string]interface{}{"handle": handle, "metadata": metadataOf(e)})
+}
+
+// Paragon_GetObjectCategory names what kind of object a handle holds, so a
+// host can tell which exports apply: "network" for any Network[T] (including
+// Paragon_Quantize and Paragon_Dequantize results), "dataset" for
+// Paragon_NewDataset, "stream" for Paragon_CallSliceStream, or "unknown".
+// Optimizers are per-network state set by Paragon_SetOptimizer rather than
+// handles of their own.
+//
+//export Paragon_GetObjectCategory
+func Paragon_GetObjectCategory(handle int64) *C.char {
+	obj, ok := get(handle)
+	if !ok {
+		return handleErr(handle, fmt.Sprintf("invalid handle %d", handle))
+	}
+	return asJSON(map[string]interface{}{
+		"handle":   handle,
+		"category": objectCategory(obj),
+		"type":     reflect.TypeOf(obj).String(),
+	})
+}
+
+func objectCategory(obj interface{}) string {
+	switch obj.(type) {
+	case *paragon.Network[float32], *paragon.Network[float64], *paragon.Network[int8]:
+		return "network"
+	case *dataset:
+		return "dataset"
+	case *sliceStream:
+		return "stream"
+	default:
+		return "unknown"
+	}
+}
+
+// Paragon_RegisterActivation makes name usable as an activation in any
+// network's configuration, computed by cb (a paragon_activation_cb). Layers
+// using it run through the bridge's CPU forward pass, which calls cb once
+// per layer with all of its pre-activations, so the FFI cost is per layer
+// rather than per neuron; training estimates the derivative by central
+// differences with two more calls per layer. Paragon itself treats the name
+// as linear, so Paragon_Call("Forward") and the GPU path ignore cb. cb must
+// be safe to call from any thread and stay valid while registered;
+// registering a name again replaces its callback. Built-in names cannot be
+// overridden.
+//
+//export Paragon_RegisterActivation
+func Paragon_RegisterActivation(name *C.char, cb C.uintptr_t) *C.char {
+	n := C.GoString(name)
+	switch {
+	case n == "":
+		return errJSON("activation name must not be empty")
+	case activations[n]:
+		return errJSON(fmt.Sprintf("%q is a built-in activation", n))
+	case cb == 0:
+		return errJSON("callback is NULL")
+	}
+
+	customMu.Lock()
+	_, replaced := customActivations[n]
+	customActivations[n] = uintptr(cb)
+	customMu.Unlock()
+	return asJSON(map[string]interface{}{
+		"name":     n,
+		"replaced": replaced,
+	})
+}
+
+// Paragon_GetActivationMetadata lists every activation the bridge accepts,
+// sorted by name, with the parameters Paragon_SetActivationParameters takes
+// for it and their defaults: [{"name":"leaky_relu","params":["alpha"],
+// "default":{"alpha":0.01},"custom":false},...]. Paragon itself exposes no
+// such metadata; this is the bridge's own table, which matches paragon's
+// built-in values, plus any Paragon_RegisterActivation names.
+//
+//export Paragon_GetActivationMetadata
+func Paragon_GetActivationMetadata() *C.char {
+	names := make([]string, 0, len(activations))
+	for name := range activations {
+		names = append(names, name)
+	}
+	customMu.RLock()
+	for name := range customActivations {
+		names = append(names, name)
+	}
+	customMu.RUnlock()
+	sort.Strings(names)
+
+	meta := make([]map[string]interface{}, len(names))
+	for i, name := range names {
+		defaults := activationParams[name]
+		params := make([]string, 0, len(defaults))
+		for p := range defaults {
+			params = append(params, p)
+		}
+		sort.Strings(params)
+		if defaults == nil {
+			defaults = map[string]float64{}
+		}
+		meta[i] = map[string]interface{}{
+			"name":    name,
+			"params":  params,
+			"default": defaults,
+			"custom":  !activations[name],
+		}
+	}
+	return asJSON(meta)
+}
+
+// Paragon_GetActivationList returns each layer's activation name in layer
+// order, input layer first, as a JSON array. It accepts any network handle,
+// including the int8 and float64 ones made by Paragon_Quantize and
+// Paragon_Dequantize.
+//
+//export Paragon_GetActivationList
+func Paragon_GetActivationList(handle int64) *C.char {
+	e, ok := getEntry(handle)
+	if !ok {
+		return handleErr(handle, fmt.Sprintf("invalid handle %d", handle))
+	}
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+
+	var acts []string
+	switch net := e.obj.(type) {
+	case *paragon.Network[float32]:
+		acts = activationList(net)
+	case *paragon.Network[float64]:
+		acts = activationList(net)
+	case *paragon.Network[int8]:
+		acts = activationList(net)
+	default:
+		return handleErr(handle, fmt.Sprintf("%T is not a network", e.obj))
+	}
+	return asJSON(acts)
+}
+
+func activationList[T paragon.Numeric](net *paragon.Network[T]) []string {
+	acts := make([]string, len(net.Layers))
+	for i, layer := range net.Layers {
+		acts[i] = layer.Neurons[0][0].Activation
+	}
+	return acts
+}
+
+// Paragon_GetLayerActivationFull reports a layer's activation (from its first
+// neuron) with the parameters in effect: paragon's defaults overridden by any
+// set with Paragon_SetActivationParameters, so "params" can be edited and
+// passed straight back. Activations without parameters report {}.
+//
+//export Paragon_GetLayerActivationFull
+func Paragon_GetLayerActivationFull(handle int64, index C.int) *C.char {
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	defer unlock()
+	l := int(index)
+	if l < 0 || l >= len(net.Layers) {
+		return handleErr(handle, fmt.Sprintf("layer index %d out of range (0..%d)", l, len(net.Layers)-1))
+	}
+	e, _ := getEntry(handle)
+
+	act := net.Layers[l].Neurons[0][0].Activation
+	params := map[string]float64{}
+	for k, v := range activationParams[act] {
+		params[k] = v
+	}
+	for k, v := range e.actParams[l] {
+		params[k] = v
+	}
+	return asJSON(map[string]interface{}{
+		"layer":  l,
+		"name":   act,
+		"params": params,
+	})
+}
+
+// Paragon_GetLayerType reports a layer's kind from paragon's per-neuron Type
+// metadata ("dense" for every layer paragon builds today), or "mixed" with
+// the distinct kinds listed if its neurons disagree.
+//
+//export Paragon_GetLayerType
+func Paragon_GetLayerType(handle int64, index C.int) *C.char {
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	defer unlock()
+	l := int(index)
+	if l < 0 || l >= len(net.Layers) {
+		return handleErr(handle, fmt.Sprintf("layer index %d out of range (0..%d)", l, len(net.Layers)-1))
+	}
+
+	seen := map[string]bool{}
+	var kinds []string
+	for _, row := range net.Layers[l].Neurons {
+		for _, neuron := range row {
+			if !seen[neuron.Type] {
+				seen[neuron.Type] = true
+				kinds = append(kinds, neuron.Type)
+			}
+		}
+	}
+	resp := map[string]interface{}{
+		"index": l,
+		"type":  kinds[0],
+		"input": l == net.InputLayer,
+	}
+	if len(kinds) > 1 {
+		resp["type"] = "mixed"
+		resp["types"] = kinds
+	}
+	return asJSON(resp)
+}
+
+// Paragon_GetComputeProfile reports, for each layer after the input, its
+// parameter count (weights plus biases) and estimated forward FLOPs:
+// 2 per connection (multiply and add) plus 1 per neuron for the bias, with
+// activation functions not counted. Totals are summed at the top level.
+//
+//export Paragon_GetComputeProfile
+func Paragon_GetComputeProfile(handle int64) *C.char {
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	defer unlock()
+
+	layers := make([]map[string]interface{}, 0, len(net.Layers)-net.InputLayer-1)
+	totalParams, totalFlops := 0, 0
+	for l := net.InputLayer + 1; l < len(net.Layers); l++ {
+		conns, neurons := 0, 0
+		for _, row := range net.Layers[l].Neurons {
+			for _, neuron := range row {
+				conns += len(neuron.Inputs)
+				neurons++
+			}
+		}
+		params, flops := conns+neurons, 2*conns+neurons
+		totalParams += params
+		totalFlops += flops
+		layers = append(layers, map[string]interface{}{
+			"index":         l,
+			"params":        params,
+			"flops_forward": flops,
+		})
+	}
+	return asJSON(map[string]interface{}{
+		"layers":        layers,
+		"params":        totalParams,
+		"flops_forward": totalFlops,
+	})
+}
+
+// Paragon_GetModelSummary returns a Keras-style summary as plain text for
+// printing: one row per layer with its index, type, output shape (width x
+// height), activation and parameter count, then the total and trainable
+// parameter counts, the latter without layers frozen by
+// Paragon_SetLayerFrozen. It is text, not JSON, so on failure it returns NULL
+// and the message is in Paragon_GetLastError.
+//
+//export Paragon_GetModelSummary
+func Paragon_GetModelSummary(handle int64) *C.char {
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		setHandleError(handle, err.Error())
+		return nil
+	}
+	defer unlock()
+	e, _ := getEntry(handle)
+
+	var b strings.Builder
+	tw := tabwriter.NewWriter(&b, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "Layer\tType\tOutput shape\tActivation\tParams")
+	total := 0
+	for l, layer := range net.Layers {
+		kind, params := "input", 0
+		if l != net.InputLayer {
+			kind = layer.Neurons[0][0].Type
+			for _, row := range layer.Neurons {
+				for _, neuron := range row {
+					params += len(neuron.Inputs) + 1
+					if neuron.Type != kind {
+						kind = "mixed"
+					}
+				}
+			}
+		}
+		total += params
+		fmt.Fprintf(tw, "%d\t%s\t%dx%d\t%s\t%d\n", l, kind, layer.Width, layer.Height, layer.Neurons[0][0].Activation, params)
+	}
+	tw.Flush()
+
+	width := 0
+	for _, line := range strings.Split(b.String(), "\n") {
+		width = max(width, len(strings.TrimRight(line, " ")))
+	}
+	rows := strings.SplitN(b.String(), "\n", 2)
+	rule := strings.Repeat("=", width)
+	return cstr(fmt.Sprintf("%s\n%s\n%s%s\nTotal params: %d\nTrainable params: %d\n",
+		strings.TrimRight(rows[0], " "), rule, rows[1], rule, total, trainableParamCount(net, e.frozen)))
+}
+
+// Paragon_SetLayerFrozen freezes or unfreezes layer layerIndex (after the
+// input) for the bridge's training exports, Paragon_TrainStep and
+// Paragon_TrainWithValidation: a frozen layer's weights and biases keep their
+// values, though gradients still flow through it to earlier layers. Training
+// through paragon's own methods via Paragon_Call ignores it.
+//
+//export Paragon_SetLayerFrozen
+func Paragon_SetLayerFrozen(handle int64, layerIndex C.int, frozen C.bool) *C.char {
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	defer unlock()
+	l := int(layerIndex)
+	if l <= net.InputLayer || l >= len(net.Layers) {
+		return handleErr(handle, fmt.Sprintf("layer index %d out of range (%d..%d)", l, net.InputLayer+1, len(net.Layers)-1))
+	}
+
+	e, _ := getEntry(handle)
+	if frozen {
+		if e.frozen == nil {
+			e.frozen = map[int]bool{}
+		}
+		e.frozen[l] = true
+	} else {
+		delete(e.frozen, l)
+	}
+	return asJSON(map[string]interface{}{
+		"handle":    handle,
+		"layer":     l,
+		"frozen":    bool(frozen),
+		"trainable": trainableParamCount(net, e.frozen),
+	})
+}
+
+// trainableParamCount is paramCount less the parameters of frozen layers.
+func trainableParamCount(net *paragon.Network[float32], frozen map[int]bool) int {
+	n := 0
+	for l := net.InputLayer + 1; l < len(net.Layers); l++ {
+		if !frozen[l] {
+			n += layerParamCount(net, l)
+		}
+	}
+	return n
+}
+
+// Paragon_GetTrainableParameterCount reports the weights and biases that
+// training updates, per layer and in total, next to the total parameter
+// count. Layers frozen with Paragon_SetLayerFrozen count as untrainable.
+//
+//export Paragon_GetTrainableParameterCount
+func Paragon_GetTrainableParameterCount(handle int64) *C.char {
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	defer unlock()
+	e, _ := getEntry(handle)
+
+	layers := make([]map[string]interface{}, 0, len(net.Layers)-net.InputLayer-1)
+	for l := net.InputLayer + 1; l < len(net.Layers); l++ {
+		layers = append(layers, map[string]interface{}{
+			"index":      l,
+			"parameters": layerParamCount(net, l),
+			"trainable":  !e.frozen[l],
+		})
+	}
+	return asJSON(map[string]interface{}{
+		"trainable": trainableParamCount(net, e.frozen),
+		"total":     paramCount(net),
+		"layers":    layers,
+	})
+}
+
+// Paragon_GetWeightSparsity reports, for each layer after the input and in
+// total, the fraction of connection weights (biases excluded) whose absolute
+// value is below threshold.
+//
+//export Paragon_GetWeightSparsity
+func Paragon_GetWeightSparsity(handle int64, threshold C.double) *C.char {
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	defer unlock()
+	if !(threshold >= 0) {
+		return handleErr(handle, fmt.Sprintf("threshold must be >= 0, got %v", float64(threshold)))
+	}
+
+	t := float64(threshold)
+	layers := make([]map[string]interface{}, 0, len(net.Layers)-net.InputLayer-1)
+	total, totalBelow := 0, 0
+	for l := net.InputLayer + 1; l < len(net.Layers); l++ {
+		count, below := 0, 0
+		for _, row := range net.Layers[l].Neurons {
+			for _, neuron := range row {
+				for _, c := range neuron.Inputs {
+					count++
+					if math.Abs(float64(c.Weight)) < t {
+						below++
+					}
+				}
+			}
+		}
+		total += count
+		totalBelow += below
+		layers = append(layers, map[string]interface{}{
+			"index":    l,
+			"weights":  count,
+			"below":    below,
+			"sparsity": fraction(below, count),
+		})
+	}
+	return asJSON(map[string]interface{}{
+		"layers":    layers,
+		"threshold": t,
+		"weights":   total,
+		"below":     totalBelow,
+		"sparsity":  fraction(totalBelow, total),
+	})
+}
+
+// Paragon_PruneWeights zeroes every connection weight whose absolute value is
+// below threshold (magnitude pruning; biases are kept) and reports how many
+// nonzero weights it zeroed. A GPU-enabled handle stays on the GPU with the
+// pruned weights.
+//
+//export Paragon_PruneWeights
+func Paragon_PruneWeights(handle int64, threshold C.double) *C.char {
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	defer unlock()
+	if !(threshold >= 0) {
+		return handleErr(handle, fmt.Sprintf("threshold must be >= 0, got %v", float64(threshold)))
+	}
+
+	t := float64(threshold)
+	pruned, total := 0, 0
+	for l := net.InputLayer + 1; l < len(net.Layers); l++ {
+		for _, row := range net.Layers[l].Neurons {
+			for _, neuron := range row {
+				for k, c := range neuron.Inputs {
+					total++
+					if c.Weight != 0 && math.Abs(float64(c.Weight)) < t {
+						neuron.Inputs[k].Weight = 0
+						pruned++
+					}
+				}
+			}
+		}
+	}
+
+	if err := syncToGPU(net); err != nil {
+		return handleErr(handle, err.Error())
+	}
+	return asJSON(map[string]interface{}{
+		"pruned":    pruned,
+		"weights":   total,
+		"threshold": t,
+	})
+}
+
+// fraction is n/d, or 0 when d is 0.
+func fraction(n, d int) float64 {
+	if d == 0 {
+		return 0
+	}
+	return float64(n) / float64(d)
+}
+
+// Paragon_GetNetworkFingerprint hashes the architecture (as reported by
+// architecture) followed by every weight and bias in the flat layout as
+// little-endian float32, so two handles match only if they compute the same
+// function with bit-identical parameters.
+//
+//export Paragon_GetNetworkFingerprint
+func Paragon_GetNetworkFingerprint(handle int64) *C.char {
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	defer unlock()
+
+	h := sha256.New()
+	arch, _ := json.Marshal(architecture(net))
+	h.Write(arch)
+	vals := flatWeights(net)
+	var b [4]byte
+	for _, v := range vals {
+		binary.LittleEndian.PutUint32(b[:], math.Float32bits(float32(v)))
+		h.Write(b[:])
+	}
+
+	return asJSON(map[string]interface{}{
+		"fingerprint": hex.EncodeToString(h.Sum(nil)),
+		"algorithm":   "sha256",
+		"parameters":  len(vals),
+	})
+}
+
+// Paragon_GetConfigChecksum hashes the network's construction config, the
+// layers/activations/fullyConnected arrays Paragon_NewNetworkFloat32 takes,
+// as derived from the network itself, and returns both. Weights and runtime
+// state such as the GPU and debug flags are left out, so the checksum
+// survives save/load cycles. A layer counts as fully connected when each
+// neuron reads every neuron of the layer before it; the input layer, whose
+// flag paragon ignores, is always reported as true.
+//
+//export Paragon_GetConfigChecksum
+func Paragon_GetConfigChecksum(handle int64) *C.char {
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	defer unlock()
+
+	config := networkConfig(net)
+	b, _ := json.Marshal(config)
+	sum := sha256.Sum256(b)
+	return asJSON(map[string]interface{}{
+		"checksum":  hex.EncodeToString(sum[:]),
+		"algorithm": "sha256",
+		"config":    config,
+	})
+}
+
+// netConfig is the argument set of paragon.NewNetwork in the JSON form the
+// constructor exports accept.
+type netConfig struct {
+	Layers         []struct{ Width, Height int } `json:"layers"`
+	Activations    []string                      `json:"activations"`
+	FullyConnected []bool                        `json:"fullyConnected"`
+}
+
+func networkConfig(net *paragon.Network[float32]) netConfig {
+	var c netConfig
+	for l, layer := range net.Layers {
+		c.Layers = append(c.Layers, struct{ Width, Height int }{layer.Width, layer.Height})
+		c.Activations = append(c.Activations, layer.Neurons[0][0].Activation)
+		c.FullyConnected = append(c.FullyConnected, l == 0 || fullyConnected(net, l))
+	}
+	return c
+}
+
+// fullyConnected reports whether every neuron of layer l reads every neuron
+// of layer l-1.
+func fullyConnected(net *paragon.Network[float32], l int) bool {
+	prev := net.Layers[l-1].Width * net.Layers[l-1].Height
+	for _, row := range net.Layers[l].Neurons {
+		for _, neuron := range row {
+			if len(neuron.Inputs) != prev {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Paragon_GetConnectivity describes which neurons of the previous layer each
+// neuron of layerIndex reads. A fully connected layer is reported as just
+// {"layer":N,"fully_connected":true}; otherwise "inputs" is an adjacency
+// list with one entry per neuron in row-major order, each listing its source
+// neurons as row-major indices (y*width+x) into the previous layer.
+//
+//export Paragon_GetConnectivity
+func Paragon_GetConnectivity(handle int64, layerIndex C.int) *C.char {
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	defer unlock()
+
+	l := int(layerIndex)
+	if l <= net.InputLayer || l >= len(net.Layers) {
+		return handleErr(handle, fmt.Sprintf("layer index %d out of range (%d..%d)", l, net.InputLayer+1, len(net.Layers)-1))
+	}
+	if fullyConnected(net, l) {
+		return asJSON(map[string]interface{}{
+			"layer":           l,
+			"fully_connected": true,
+		})
+	}
+
+	prevWidth := net.Layers[l-1].Width
+	inputs := make([][]int, 0, net.Layers[l].Width*net.Layers[l].Height)
+	for _, row := range net.Layers[l].Neurons {
+		for _, neuron := range row {
+			src := make([]int, len(neuron.Inputs))
+			for k, c := range neuron.Inputs {
+				if c.SourceLayer != l-1 {
+					return handleErr(handle, fmt.Sprintf("layer %d reads from layer %d, not the previous layer", l, c.SourceLayer))
+				}
+				src[k] = c.SourceY*prevWidth + c.SourceX
+			}
+			inputs = append(inputs, src)
+		}
+	}
+	return asJSON(map[string]interface{}{
+		"layer":           l,
+		"fully_connected": false,
+		"source_width":    prevWidth,
+		"source_height":   net.Layers[l-1].Height,
+		"inputs":          inputs,
+	})
+}
+
+// Paragon_CompareToFile loads the golden model at goldenPath (paragon's JSON
+// model format, as written by SaveJSON) and compares every weight and bias
+// against the handle's. The architectures must agree layer for layer (shape,
+// activation and each neuron's fan-in) or an error describing the first
+// difference is returned instead of a diff. match is true when no parameter
+// differs by more than tolerance; first_mismatch_layer is the first layer
+// that does, or null.
+//
+//export Paragon_CompareToFile
+func Paragon_CompareToFile(handle int64, goldenPath *C.char, tolerance C.double) *C.char {
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	defer unlock()
+	if !(tolerance >= 0) {
+		return handleErr(handle, fmt.Sprintf("tolerance must be >= 0, got %v", float64(tolerance)))
+	}
+
+	path := C.GoString(goldenPath)
+	golden := &paragon.Network[float32]{TypeName: net.TypeName}
+	if err := golden.LoadJSON(path); err != nil {
+		return handleErr(handle, fmt.Sprintf("load %s: %v", path, err))
+	}
+	if err := sameArchitecture(net, golden); err != nil {
+		return handleErr(handle, fmt.Sprintf("architecture mismatch with %s: %v", path, err))
+	}
+
+	tol := float64(tolerance)
+	maxDiff := 0.0
+	var firstMismatch interface{}
+	for l := net.InputLayer + 1; l < len(net.Layers); l++ {
+		layerDiff := 0.0
+		for y, row := range net.Layers[l].Neurons {
+			for x, neuron := range row {
+				g := golden.Layers[l].Neurons[y][x]
+				layerDiff = math.Max(layerDiff, math.Abs(float64(neuron.Bias)-float64(g.Bias)))
+				for k, c := range neuron.Inputs {
+					layerDiff = math.Max(layerDiff, math.Abs(float64(c.Weight)-float64(g.Inputs[k].Weight)))
+				}
+			}
+		}
+		if (layerDiff > tol || math.IsNaN(layerDiff)) && firstMismatch == nil {
+			firstMismatch = l
+		}
+		if layerDiff > maxDiff || math.IsNaN(layerDiff) {
+			maxDiff = layerDiff
+		}
+	}
+
+	return asJSON(map[string]interface{}{
+		"match":                firstMismatch == nil,
+		"max_abs_diff":         maxDiff,
+		"first_mismatch_layer": firstMismatch,
+		"tolerance":            tol,
+	})
+}
+
+// sameArchitecture reports the first layer where a and b differ in shape,
+// activation or connectivity.
+func sameArchitecture(a, b *paragon.Network[float32]) error {
+	if len(a.Layers) != len(b.Layers) {
+		return fmt.Errorf("%d layers vs %d", len(a.Layers), len(b.Layers))
+	}
+	for l := range a.Layers {
+		la, lb := a.Layers[l], b.Layers[l]
+		if la.Width != lb.Width || la.Height != lb.Height {
+			return fmt.Errorf("layer %d is %dx%d vs %dx%d", l, la.Width, la.Height, lb.Width, lb.Height)
+		}
+		for y, row := range la.Neurons {
+			for x, na := range row {
+				nb := lb.Neurons[y][x]
+				if na.Activation != nb.Activation {
+					return fmt.Errorf("layer %d neuron (%d,%d) activation %q vs %q", l, x, y, na.Activation, nb.Activation)
+				}
+				if len(na.Inputs) != len(nb.Inputs) {
+					return fmt.Errorf("layer %d neuron (%d,%d) has %d inputs vs %d", l, x, y, len(na.Inputs), len(nb.Inputs))
+				}
+				for k, c := range na.Inputs {
+					if c.SourceLayer != nb.Inputs[k].SourceLayer || c.SourceX != nb.Inputs[k].SourceX || c.SourceY != nb.Inputs[k].SourceY {
+						return fmt.Errorf("layer %d neuron (%d,%d) input %d connects to a different source", l, x, y, k)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+//export Paragon_EnableGPU
+func Paragon_EnableGPU(handle int64) *C.char {
+	obj, ok := get(handle)
+	if !ok {
+		return handleErr(handle, "invalid handle")
+	}
+
+	net, ok := obj.(*paragon.Network[float32])
+	if !ok {
+		return handleErr(handle, "not a Network[float32]")
+	}
+
+	net.WebGPUNative = true
+	if err := net.InitializeOptimizedGPU(); err != nil {
+		net.WebGPUNative = false
+		return handleErr(handle, "failed to initialize GPU: "+err.Error())
+	}
+
+	return asJSON(map[string]interface{}{
+		"status": "GPU enabled",
+		"handle": handle,
+	})
+}
+
+//export Paragon_DisableGPU
+func Paragon_DisableGPU(handle int64) *C.char {
+	obj, ok := get(handle)
+	if !ok {
+		return handleErr(handle, "invalid handle")
+	}
+
+	net, ok := obj.(*paragon.Network[float32])
+	if !ok {
+		return handleErr(handle, "not a Network[float32]")
+	}
+
+	net.CleanupOptimizedGPU()
+	net.WebGPUNative = false
+
+	return asJSON(map[string]interface{}{
+		"status": "GPU disabled",
+		"handle": handle,
+	})
+}
+
+// Paragon_DuplicateToGPU deep-copies a network, weights and activation
+// parameters included, into a new GPU-enabled handle in the source's
+// namespace, leaving the source as it was. If GPU initialization fails the
+// copy is discarded and no handle is created.
+//
+//export Paragon_DuplicateToGPU
+func Paragon_DuplicateToGPU(handle int64) *C.char {
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	defer unlock()
+	e, _ := getEntry(handle)
+
+	dup, err := paragon.ConvertNetwork[float32, float32](net)
+	if err != nil {
+		return handleErr(handle, "copy: "+err.Error())
+	}
+	dup.WebGPUNative = true
+	if err := dup.InitializeOptimizedGPU(); err != nil {
+		dup.CleanupOptimizedGPU()
+		return handleErr(handle, "failed to initialize GPU for copy: "+err.Error())
+	}
+
+	id, ok := putIn(namespaceOf(handle), dup)
+	if !ok {
+		dup.CleanupOptimizedGPU()
+		return handleErr(handle, fmt.Sprintf("namespace %d was freed", namespaceOf(handle)))
+	}
+	if len(e.actParams) > 0 {
+		d, _ := getEntry(id)
+		d.actParams = map[int]map[string]float64{}
+		for l, params := range e.actParams {
+			d.actParams[l] = params
+		}
+	}
+	return asJSON(map[string]interface{}{
+		"handle": id,
+		"source": handle,
+		"type":   "Network[float32]",
+		"gpu":    true,
+	})
+}
+
+// Paragon_CreateFromTemplate creates a new network handle in the template's
+// namespace with the same architecture and activation parameters. With
+// shareWeights false it is a deep copy, on the GPU if the template is.
+//
+// With shareWeights true the new network aliases the template's connection
+// weights: each neuron's weight slice is the template's, so a weight update
+// through either handle (or any further handle made from either) is seen by
+// all of them. Biases live inline in paragon's neurons and cannot be shared;
+// each network starts with the template's biases and trains its own.
+// Aliasing ends for a neuron whose connections are rebuilt, e.g. by
+// Paragon_ReplaceOutputLayer or growth. Networks sharing weights also share
+// one handle lock, so training or DropConnect on one (which writes the shared
+// weights) never overlaps a call on another; they run one at a time. A shared
+// network is created on the CPU, and GPU copies are never shared, so a GPU
+// handle does not see updates made through another handle until its weights
+// are re-uploaded.
+//
+//export Paragon_CreateFromTemplate
+func Paragon_CreateFromTemplate(templateHandle int64, shareWeights C.bool) *C.char {
+	net, unlock, err := lockNetwork(templateHandle)
+	if err != nil {
+		return handleErr(templateHandle, err.Error())
+	}
+	defer unlock()
+	e, _ := getEntry(templateHandle)
+
+	dup, err := paragon.ConvertNetwork[float32, float32](net)
+	if err != nil {
+		return handleErr(templateHandle, "copy: "+err.Error())
+	}
+	shared := bool(shareWeights)
+	if shared {
+		for l, layer := range net.Layers {
+			for y, row := range layer.Neurons {
+				for x, neuron := range row {
+					dup.Layers[l].Neurons[y][x].Inputs = neuron.Inputs
+				}
+			}
+		}
+	} else if net.WebGPUNative {
+		dup.WebGPUNative = true
+		if err := dup.InitializeOptimizedGPU(); err != nil {
+			dup.CleanupOptimizedGPU()
+			return handleErr(templateHandle, "failed to initialize GPU for copy: "+err.Error())
+		}
+	}
+
+	lock := new(sync.RWMutex)
+	if shared {
+		lock = e.lock
+	}
+	id, ok := putInLocked(namespaceOf(templateHandle), dup, lock)
+	if !ok {
+		dup.CleanupOptimizedGPU()
+		return handleErr(templateHandle, fmt.Sprintf("namespace %d was freed", namespaceOf(templateHandle)))
+	}
+	if len(e.actParams) > 0 {
+		d, _ := getEntry(id)
+		d.actParams = map[int]map[string]float64{}
+		for l, params := range e.actParams {
+			d.actParams[l] = params
+		}
+	}
+	return asJSON(map[string]interface{}{
+		"handle":         id,
+		"template":       templateHandle,
+		"shared_weights": shared,
+		"gpu":            dup.WebGPUNative,
+	})
+}
+
+// Paragon_GetComputeDevice reports where a network's forward pass runs:
+// {"device":"cpu"}, or for a GPU-enabled handle the WebGPU adapter's name,
+// vendor, type and backend, e.g. {"device":"gpu","adapter":"NVIDIA GeForce
+// RTX 4090","backend":"vulkan",...}.
+//
+//export Paragon_GetComputeDevice
+func Paragon_GetComputeDevice(handle int64) *C.char {
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	gpu := net.WebGPUNative
+	unlock()
+	if !gpu {
+		return asJSON(map[string]string{"device": "cpu"})
+	}
+
+	info, err := gpuAdapterInfo()
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	return asJSON(map[string]string{
+		"device":       "gpu",
+		"adapter":      info.Name,
+		"vendor":       info.VendorName,
+		"adapter_type": info.AdapterType.String(),
+		"backend":      info.BackendType.String(),
+	})
+}
+
+// Paragon_SetComputePrecision selects the GPU forward pass's arithmetic
+// precision. Paragon generates its WGSL kernels with f32 storage and math and
+// has no half-precision variant, so "fp32" is the only precision it can run:
+// it is accepted for any handle, and for a GPU handle the response says
+// whether the adapter could run fp16 (adapter_fp16). "fp16" is refused with
+// the reason: the handle is on the CPU, the adapter lacks the shader-f16
+// feature, or paragon cannot compute in it. fp16 would keep about three
+// significant digits per activation and overflow past 65504, so it would
+// suit inference on well-scaled inputs and not training.
+//
+//export Paragon_SetComputePrecision
+func Paragon_SetComputePrecision(handle int64, precision *C.char) *C.char {
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	gpu := net.WebGPUNative
+	unlock()
+
+	switch p := C.GoString(precision); p {
+	case "fp32":
+		resp := map[string]interface{}{
+			"handle":    handle,
+			"precision": "fp32",
+			"gpu":       gpu,
+		}
+		if gpu {
+			if _, err := gpuAdapterInfo(); err == nil {
+				resp["adapter_fp16"] = adapterF16
+			}
+		}
+		return asJSON(resp)
+	case "fp16":
+		if !gpu {
+			return handleErr(handle, "fp16 applies to the GPU forward pass; handle is on the CPU")
+		}
+		if _, err := gpuAdapterInfo(); err != nil {
+			return handleErr(handle, err.Error())
+		}
+		if !adapterF16 {
+			return handleErr(handle, "adapter does not support shader-f16")
+		}
+		return handleErr(handle, "fp16 is not supported: paragon's GPU kernels compute in fp32 only")
+	default:
+		return handleErr(handle, fmt.Sprintf("unknown precision %q (want fp32 or fp16)", p))
+	}
+}
+
+var (
+	adapterMu   sync.Mutex
+	adapterInfo *wgpu.AdapterInfo
+	adapterF16  bool // whether that adapter has shader-f16; set with it
+)
+
+// gpuAdapterInfo describes the adapter paragon binds its GPU networks to.
+// Paragon keeps that adapter private, so this repeats its selection (high
+// performance, else the default) on a separate instance. A successful lookup
+// is cached for the life of the process; a failed one is retried on the next
+// call. The instance and adapter found are deliberately never released: on
+// the GL backend releasing them tears down the display paragon's own device
+// uses.
+func gpuAdapterInfo() (wgpu.AdapterInfo, error) {
+	adapterMu.Lock()
+	defer adapterMu.Unlock()
+	if adapterInfo != nil {
+		return *adapterInfo, nil
+	}
+
+	instance := wgpu.CreateInstance(nil)
+	if instance == nil {
+		return wgpu.AdapterInfo{}, fmt.Errorf("failed to create WebGPU instance")
+	}
+	adapter, err := instance.RequestAdapter(&wgpu.RequestAdapterOptions{
+		PowerPreference: wgpu.PowerPreferenceHighPerformance,
+	})
+	if err != nil {
+		adapter, err = instance.RequestAdapter(&wgpu.RequestAdapterOptions{})
+	}
+	if err != nil {
+		instance.Release()
+		return wgpu.AdapterInfo{}, fmt.Errorf("failed to request adapter: %v", err)
+	}
+	info := adapter.GetInfo()
+	adapterInfo = &info
+	adapterF16 = adapter.HasFeature(wgpu.FeatureNameShaderF16)
+	return info, nil
+}
+
+// Paragon_CompareCPUGPU runs the same input through the CPU and GPU forward
+// paths and reports how far apart the outputs are. GPU resources are only
+// created for the comparison if the handle was CPU-only, and the handle's
+// original GPU state is restored either way. paragon's Forward quietly falls
+// back to the CPU when the GPU pass fails, which would make the two outputs
+// trivially equal, so the GPU side is run without that fallback and any
+// failure is returned as an error instead.
+//
+//export Paragon_CompareCPUGPU
+func Paragon_CompareCPUGPU(handle int64, input *C.float, length C.int) *C.char {
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	defer unlock()
+
+	in, err := inputFromC(net, input, length)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	touch(handle)
+
+	wasGPU := net.WebGPUNative
+
+	net.WebGPUNative = false
+	net.Forward(in)
+	cpuOut := net.GetOutput()
+
+	net.WebGPUNative = true
+	if !wasGPU {
+		if err := net.InitializeOptimizedGPU(); err != nil {
+			net.CleanupOptimizedGPU()
+			net.WebGPUNative = false
+			return handleErr(handle, "failed to initialize GPU: "+err.Error())
+		}
+	}
+	gpuErr := forwardGPUStrict(net, in)
+	gpuOut := net.GetOutput()
+
+	if !wasGPU {
+		net.CleanupOptimizedGPU()
+		net.WebGPUNative = false
+	}
+	if gpuErr != nil {
+		return handleErr(handle, "GPU forward failed: "+gpuErr.Error())
+	}
+
+	maxDiff, sumDiff := 0.0, 0.0
+	for i := range cpuOut {
+		d := math.Abs(cpuOut[i] - gpuOut[i])
+		sumDiff += d
+		if d > maxDiff {
+			maxDiff = d
+		}
+	}
+	meanDiff := 0.0
+	if len(cpuOut) > 0 {
+		meanDiff = sumDiff / float64(len(cpuOut))
+	}
+
+	return asJSON(map[string]interface{}{
+		"max_abs_diff":      maxDiff,
+		"mean_abs_diff":     meanDiff,
+		"agree_within_1e-4": maxDiff <= 1e-4,
+		"gpu":               net.WebGPUNative,
+	})
+}
+
+// forwardGPUStrict runs the optimized GPU forward pass without the CPU
+// fallback of paragon's Forward. A handle whose GPU state was released
+// underneath it makes paragon panic; that is returned as an error too.
+func forwardGPUStrict(net *paragon.Network[float32], in [][]float64) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("GPU state not initialized: %v", r)
+		}
+	}()
+	return net.ForwardGPUOptimized(in)
+}
+
+// Paragon_ScoreFile streams samples of sampleLen little-endian float32 values
+// from inputPath, runs Forward on each, and writes each output (little-endian
+// float32, output-layer size per sample) to outputPath. A file that ends in
+// the middle of a sample is an error; outputs already written are kept.
+//
+//export Paragon_ScoreFile
+func Paragon_ScoreFile(handle int64, inputPath, outputPath *C.char, sampleLen C.int) *C.char {
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	defer unlock()
+
+	if err := checkInputShape(net, int(sampleLen)); err != nil {
+		return handleErr(handle, err.Error())
+	}
+	touch(handle)
+	e, _ := getEntry(handle)
+
+	src, err := os.Open(C.GoString(inputPath))
+	if err != nil {
+		return handleErr(handle, "input: "+err.Error())
+	}
+	defer src.Close()
+	dst, err := os.Create(C.GoString(outputPath))
+	if err != nil {
+		return handleErr(handle, "output: "+err.Error())
+	}
+	defer dst.Close()
+
+	count, outLen, err := scoreStream(e, net, src, dst, int(sampleLen), 1)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	return asJSON(map[string]interface{}{
+		"processed":   count,
+		"output_size": outLen,
+	})
+}
+
+// Paragon_BatchForwardToFile is Paragon_ScoreFile for inputs too large to
+// hold: it reads batchSize samples at a time, so memory stays bounded by one
+// batch, and appends the outputs to outputPath (created if missing) instead
+// of replacing it. A short final batch is scored as is. On a read or write
+// error, the outputs of batches already scored stay in the file and the error
+// says how many samples that was. Returns the sample count, the output size
+// and the throughput in samples per second.
+//
+//export Paragon_BatchForwardToFile
+func Paragon_BatchForwardToFile(handle int64, inputPath, outputPath *C.char, sampleLen, batchSize C.int) *C.char {
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	defer unlock()
+
+	if err := checkInputShape(net, int(sampleLen)); err != nil {
+		return handleErr(handle, err.Error())
+	}
+	if batchSize <= 0 {
+		return handleErr(handle, fmt.Sprintf("batch size must be positive, got %d", int(batchSize)))
+	}
+	touch(handle)
+	e, _ := getEntry(handle)
+
+	src, err := os.Open(C.GoString(inputPath))
+	if err != nil {
+		return handleErr(handle, "input: "+err.Error())
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(C.GoString(outputPath), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return handleErr(handle, "output: "+err.Error())
+	}
+	defer dst.Close()
+
+	start := time.Now()
+	count, outLen, err := scoreStream(e, net, src, dst, int(sampleLen), int(batchSize))
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	elapsed := time.Since(start)
+	rate := 0.0
+	if elapsed > 0 {
+		rate = float64(count) / elapsed.Seconds()
+	}
+	return asJSON(map[string]interface{}{
+		"processed":          count,
+		"output_size":        outLen,
+		"elapsed_ms":         millis(elapsed),
+		"samples_per_second": rate,
+	})
+}
+
+// scoreStream runs Forward on samples of sampleLen little-endian float32
+// values read from r, batch samples per read, and writes each output to w in
+// the same format. It returns the samples scored and the output size; on
+// error, every output scored so far has been written.
+func scoreStream(e *entry, net *paragon.Network[float32], r io.Reader, w io.Writer, sampleLen, batch int) (count, outLen int, err error) {
+	in := net.Layers[net.InputLayer]
+	br, bw := bufio.NewReader(r), bufio.NewWriter(w)
+	sampleBytes := 4 * sampleLen
+	raw := make([]byte, sampleBytes*batch)
+	sample := make([][]float64, in.Height)
+	for y := range sample {
+		sample[y] = make([]float64, in.Width)
+	}
+	var b [4]byte
+	for {
+		n, err := io.ReadFull(br, raw)
+		if err == io.EOF {
+			break
+		}
+		if err != nil && err != io.ErrUnexpectedEOF {
+			bw.Flush()
+			return count, outLen, fmt.Errorf("read sample %d: %v", count, err)
+		}
+
+		for s := 0; s+sampleBytes <= n; s += sampleBytes {
+			for i := 0; i < sampleLen; i++ {
+				v := math.Float32frombits(binary.LittleEndian.Uint32(raw[s+4*i:]))
+				sample[i/in.Width][i%in.Width] = float64(v)
+			}
+			runForward(e, net, sample)
+			out := net.GetOutput()
+			outLen = len(out)
+			for _, v := range out {
+				binary.LittleEndian.PutUint32(b[:], math.Float32bits(float32(v)))
+				if _, err := bw.Write(b[:]); err != nil {
+					return count, outLen, fmt.Errorf("write sample %d: %v", count, err)
+				}
+			}
+			count++
+		}
+		if rem := n % sampleBytes; rem != 0 {
+			bw.Flush()
+			return count, outLen, fmt.Errorf("truncated input: sample %d has %d of %d bytes (%d samples scored)", count, rem, sampleBytes, count)
+		}
+		if err == io.ErrUnexpectedEOF {
+			break
+		}
+	}
+
+	if err := bw.Flush(); err != nil {
+		return count, outLen, fmt.Errorf("output: %v", err)
+	}
+	return count, outLen, nil
+}
+
+// Paragon_SetGPUFallback makes forward-family Paragon_Call methods (names
+// starting with "Forward") on a GPU handle retry once on the CPU when the GPU
+// call panics or returns an error. A result obtained that way is wrapped as
+// {"result":[...], "fell_back_to_cpu":true, "gpu_error":"..."}; the handle
+// stays GPU-enabled for later calls.
+//
+//export Paragon_SetGPUFallback
+func Paragon_SetGPUFallback(handle int64, enabled C.bool) *C.char {
+	if _, err := getNetwork(handle); err != nil {
+		return handleErr(handle, err.Error())
+	}
+	e, _ := getEntry(handle)
+
+	e.lock.Lock()
+	e.gpuFallback = bool(enabled)
+	e.lock.Unlock()
+
+	return asJSON(map[string]interface{}{
+		"handle":       handle,
+		"gpu_fallback": bool(enabled),
+	})
+}
+
+// Paragon_PerturbWeights adds N(0, magnitude) noise to every weight, as
+// paragon's PerturbWeights does, but drawn from the selected random backend
+// (see Paragon_SetRandomBackend) seeded with seed.
+//
+//export Paragon_PerturbWeights
+func Paragon_PerturbWeights(handle int64, magnitude float64, seed int64) *C.char {
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	defer unlock()
+
+	rng := newRNG(seed)
+	for l := net.InputLayer + 1; l < len(net.Layers); l++ {
+		for _, row := range net.Layers[l].Neurons {
+			for _, neuron := range row {
+				for k := range neuron.Inputs {
+					neuron.Inputs[k].Weight += float32(rng.NormFloat64() * magnitude)
+				}
+			}
+		}
+	}
+	if err := syncToGPU(net); err != nil {
+		return handleErr(handle, err.Error())
+	}
+	return asJSON(map[string]string{"status": "weights perturbed"})
+}
+
+//export Paragon_ValidateInputShape
+func Paragon_ValidateInputShape(handle int64, length C.int) *C.char {
+	net, err := getNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+
+	in := net.Layers[net.InputLayer]
+	return asJSON(map[string]interface{}{
+		"valid":    checkInputShape(net, int(length)) == nil,
+		"expected": in.Width * in.Height,
+		"got":      int(length),
+	})
+}
+
+//export Paragon_PreallocateForward
+func Paragon_PreallocateForward(handle int64) *C.char {
+	e, ok := getEntry(handle)
+	if !ok {
+		return handleErr(handle, fmt.Sprintf("invalid handle %d", handle))
+	}
+	net, ok := e.obj.(*paragon.Network[float32])
+	if !ok {
+		return handleErr(handle, "not a Network[float32]")
+	}
+	// The buffer is the one Paragon_ForwardReuse writes under e.lock.
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	out := net.Layers[net.OutputLayer]
+	n := out.Width * out.Height
+
+	if e.outBuf != nil && e.outLen != n {
+		C.free(unsafe.Pointer(e.outBuf))
+		e.outBuf = nil
+	}
+	if e.outBuf == nil {
+		e.outBuf = (*C.float)(C.malloc(C.size_t(n) * C.size_t(unsafe.Sizeof(C.float(0)))))
+		e.outLen = n
+	}
+
+	return asJSON(map[string]interface{}{
+		"handle": handle,
+		"length": n,
+	})
+}
+
+// Paragon_ForwardReuse runs Forward and writes the output into the buffer
+// allocated by Paragon_PreallocateForward, returning that same pointer.
+//
+// The buffer belongs to the handle: it is overwritten by the next
+// Paragon_ForwardReuse on the same handle, reallocated by
+// Paragon_PreallocateForward, and released by Paragon_Free. Copy the values
+// out if they must outlive the next call, and never free the pointer
+// yourself. Returns NULL on failure; see Paragon_GetLastError.
+//
+//export Paragon_ForwardReuse
+func Paragon_ForwardReuse(handle int64, input *C.float, length C.int) *C.float {
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		setHandleError(handle, err.Error())
+		return nil
+	}
+	defer unlock()
+	e, _ := getEntry(handle)
+	if e.outBuf == nil {
+		setHandleError(handle, "no preallocated output buffer; call Paragon_PreallocateForward first")
+		return nil
+	}
+	touch(handle)
+
+	in, err := inputFromC(net, input, length)
+	if err != nil {
+		setHandleError(handle, err.Error())
+		return nil
+	}
+
+	runForward(e, net, in)
+	out := net.GetOutput()
+	if len(out) != e.outLen {
+		setHandleError(handle, fmt.Sprintf("output size changed from %d to %d; call Paragon_PreallocateForward again", e.outLen, len(out)))
+		return nil
+	}
+
+	dst := unsafe.Slice((*float32)(unsafe.Pointer(e.outBuf)), e.outLen)
+	for i, v := range out {
+		dst[i] = float32(v)
+	}
+	return e.outBuf
+}
+
+// Paragon_ForwardFromUint8 runs Forward on a byte buffer such as raw image
+// pixels, each byte multiplied by scale (e.g. 1/255 for [0,1] input) as it is
+// converted. Returns a buffer of the output layer's size to be released with
+// Paragon_FreeFloatBuffer, or NULL on failure.
+//
+//export Paragon_ForwardFromUint8
+func Paragon_ForwardFromUint8(handle int64, data *C.uchar, length C.int, scale C.double) *C.float {
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		setHandleError(handle, err.Error())
+		return nil
+	}
+	defer unlock()
+	if err := checkInputShape(net, int(length)); err != nil {
+		setHandleError(handle, err.Error())
+		return nil
+	}
+	if data == nil {
+		setHandleError(handle, "input buffer is NULL")
+		return nil
+	}
+	touch(handle)
+	e, _ := getEntry(handle)
+
+	src := unsafe.Slice((*byte)(unsafe.Pointer(data)), int(length))
+	flat := make([]float64, len(src))
+	for i, b := range src {
+		flat[i] = float64(b) * float64(scale)
+	}
+	runForward(e, net, inputGrid(net, flat))
+
+	return floatBuf(net.GetOutput())
+}
+
+// Paragon_ForwardToLayer runs the forward pass on the CPU only as far as
+// stopLayer and returns that layer's activations (row-major) for use as
+// features; later layers are not computed. stopLayer may be any layer from
+// the input to the output. Returns a buffer of the layer's size to be
+// released with Paragon_FreeFloatBuffer, or NULL on failure.
+//
+//export Paragon_ForwardToLayer
+func Paragon_ForwardToLayer(handle int64, input *C.float, length C.int, stopLayer C.int) *C.float {
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		setHandleError(handle, err.Error())
+		return nil
+	}
+	defer unlock()
+	l := int(stopLayer)
+	if l < net.InputLayer || l > net.OutputLayer {
+		setHandleError(handle, fmt.Sprintf("layer index %d out of range (%d..%d)", l, net.InputLayer, net.OutputLayer))
+		return nil
+	}
+	in, err := inputFromC(net, input, length)
+	if err != nil {
+		setHandleError(handle, err.Error())
+		return nil
+	}
+	touch(handle)
+	e, _ := getEntry(handle)
+
+	e.forwards.Add(1)
+	recordInput(e, in)
+	forwardCPUTo(net, e.actParams, in, l, nil)
+
+	layer := net.Layers[l]
+	vals := make([]float64, 0, layer.Width*layer.Height)
+	for _, row := range layer.Neurons {
+		for _, neuron := range row {
+			vals = append(vals, float64(neuron.Value))
+		}
+	}
+	return floatBuf(vals)
+}
+
+// Paragon_ForwardProfileLayers runs one forward pass on the CPU and reports
+// the wall time of each layer after the input; total_ms also covers loading
+// the input and any output softmax. Paragon's GPU forward is a single
+// submission with no per-layer timestamps, so GPU handles are refused rather
+// than profiled on a path they do not run.
+//
+//export Paragon_ForwardProfileLayers
+func Paragon_ForwardProfileLayers(handle int64, input *C.float, length C.int) *C.char {
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	defer unlock()
+	if net.WebGPUNative {
+		return handleErr(handle, "per-layer profiling is CPU-only: paragon's GPU forward has no per-layer timing")
+	}
+	in, err := inputFromC(net, input, length)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	touch(handle)
+	e, _ := getEntry(handle)
+
+	e.forwards.Add(1)
+	recordInput(e, in)
+	layers := make([]map[string]interface{}, 0, net.OutputLayer-net.InputLayer)
+	start := time.Now()
+	mark := start
+	forwardCPU(net, e.actParams, in, func(l int) {
+		now := time.Now()
+		layers = append(layers, map[string]interface{}{
+			"index": l,
+			"ms":    millis(now.Sub(mark)),
+		})
+		mark = now
+	})
+	return asJSON(map[string]interface{}{
+		"layers":   layers,
+		"total_ms": millis(time.Since(start)),
+	})
+}
+
+// millis converts d to fractional milliseconds at microsecond resolution.
+func millis(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// Paragon_ForwardSparse runs Forward on a sparse input of inputSize values
+// given as nnz index/value pairs; every other value is zero and values at a
+// repeated index are summed. inputSize must match the input layer. Returns a
+// buffer of the output layer's size to be released with
+// Paragon_FreeFloatBuffer, or NULL on failure.
+//
+//export Paragon_ForwardSparse
+func Paragon_ForwardSparse(handle int64, indices *C.int, values *C.float, nnz C.int, inputSize C.int) *C.float {
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		setHandleError(handle, err.Error())
+		return nil
+	}
+	defer unlock()
+	if err := checkInputShape(net, int(inputSize)); err != nil {
+		setHandleError(handle, err.Error())
+		return nil
+	}
+	if nnz < 0 {
+		setHandleError(handle, fmt.Sprintf("nnz must be >= 0, got %d", int(nnz)))
+		return nil
+	}
+	if nnz > 0 && (indices == nil || values == nil) {
+		setHandleError(handle, "index or value buffer is NULL")
+		return nil
+	}
+	touch(handle)
+	e, _ := getEntry(handle)
+
+	flat := make([]float64, int(inputSize))
+	if nnz > 0 {
+		idx := unsafe.Slice((*int32)(unsafe.Pointer(indices)), int(nnz))
+		vals := unsafe.Slice((*float32)(unsafe.Pointer(values)), int(nnz))
+		for k, i := range idx {
+			if i < 0 || int(i) >= len(flat) {
+				setHandleError(handle, fmt.Sprintf("index %d at position %d out of range (0..%d)", i, k, len(flat)-1))
+				return nil
+			}
+			flat[i] += float64(vals[k])
+		}
+	}
+	runForward(e, net, inputGrid(net, flat))
+
+	return floatBuf(net.GetOutput())
+}
+
+// Paragon_ForwardWithDropout runs a CPU forward pass with inverted dropout on
+// every hidden layer: each hidden activation is zeroed with probability
+// dropoutRate and survivors are scaled by 1/(1-dropoutRate). The mask is drawn
+// from seed, so the same seed gives the same output; repeat with different
+// seeds for Monte Carlo dropout. In eval mode (see Paragon_SetMode) no
+// dropout is applied and this is a plain forward pass. Returns a buffer of
+// the output layer's size to be released with Paragon_FreeFloatBuffer, or
+// NULL on failure.
+//
+//export Paragon_ForwardWithDropout
+func Paragon_ForwardWithDropout(handle int64, input *C.float, length C.int, dropoutRate C.double, seed int64) *C.float {
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		setHandleError(handle, err.Error())
+		return nil
+	}
+	defer unlock()
+	rate := float64(dropoutRate)
+	if rate < 0 || rate >= 1 {
+		setHandleError(handle, fmt.Sprintf("dropout rate %v out of range [0,1)", rate))
+		return nil
+	}
+	in, err := inputFromC(net, input, length)
+	if err != nil {
+		setHandleError(handle, err.Error())
+		return nil
+	}
+	touch(handle)
+	e, _ := getEntry(handle)
+	if e.evalMode {
+		runForward(e, net, in)
+		return floatBuf(net.GetOutput())
+	}
+
+	rng := newRNG(seed)
+	keep := float32(1 / (1 - rate))
+	e.forwards.Add(1)
+	recordInput(e, in)
+	forwardCPU(net, e.actParams, in, func(l int) {
+		if l == net.OutputLayer {
+			return
+		}
+		for _, row := range net.Layers[l].Neurons {
+			for _, neuron := range row {
+				if rng.Float64() < rate {
+					neuron.Value = 0
+				} else {
+					neuron.Value *= keep
+				}
+			}
+		}
+	})
+
+	return floatBuf(net.GetOutput())
+}
+
+// Paragon_ForwardBatchAsync copies batch samples of sampleLen floats from data
+// and returns at once; a goroutine then runs Forward on each sample in order
+// and calls cb (a paragon_batch_cb) with the concatenated outputs,
+// batch * output size floats. data may be reused as soon as this returns. The
+// result buffer and error string belong to the bridge and are freed when cb
+// returns, so copy anything needed later. cb runs on a thread the bridge
+// chooses, once per submission; after Paragon_CancelAllTasks it reports
+// "task canceled" instead of outputs.
+//
+//export Paragon_ForwardBatchAsync
+func Paragon_ForwardBatchAsync(handle int64, data *C.float, batch, sampleLen C.int, cb C.uintptr_t) *C.char {
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	err = checkInputShape(net, int(sampleLen))
+	unlock()
+	switch {
+	case err != nil:
+		return handleErr(handle, err.Error())
+	case batch < 1:
+		return handleErr(handle, fmt.Sprintf("batch must be >= 1, got %d", int(batch)))
+	case data == nil:
+		return handleErr(handle, "input buffer is NULL")
+	case cb == 0:
+		return handleErr(handle, "callback is NULL")
+	}
+	touch(handle)
+
+	src := unsafe.Slice((*float32)(unsafe.Pointer(data)), int(batch)*int(sampleLen))
+	flat := make([]float64, len(src))
+	for i, v := range src {
+		flat[i] = float64(v)
+	}
+
+	t := newTask()
+	go func() {
+		defer t.done()
+		out, err := forwardBatchFlat(t, handle, flat, int(sampleLen))
+		if err != nil {
+			msg := C.CString(err.Error())
+			defer C.free(unsafe.Pointer(msg))
+			C.call_batch_cb(cb, C.int64_t(handle), nil, 0, msg)
+			return
+		}
+		buf := floatBuf(out)
+		defer C.free(unsafe.Pointer(buf))
+		C.call_batch_cb(cb, C.int64_t(handle), buf, C.int(len(out)), nil)
+	}()
+
+	return asJSON(map[string]interface{}{
+		"status": "submitted",
+		"handle": handle,
+		"batch":  int(batch),
+	})
+}
+
+// forwardBatchFlat runs each sampleLen-sized sample of flat through the
+// handle's network and concatenates the outputs, giving up between samples
+// once t is canceled. A panic in the forward pass is returned as an error:
+// it happens on the submission's goroutine, where nothing else recovers it.
+func forwardBatchFlat(t *task, handle int64, flat []float64, sampleLen int) (out []float64, err error) {
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	defer func() {
+		if r := recover(); r != nil {
+			out, err = nil, fmt.Errorf("panic: %v", r)
+		}
+	}()
+	e, _ := getEntry(handle)
+
+	for i := 0; i+sampleLen <= len(flat); i += sampleLen {
+		if t.canceled.Load() {
+			return nil, errTaskCanceled
+		}
+		runForward(e, net, inputGrid(net, flat[i:i+sampleLen]))
+		out = append(out, net.GetOutput()...)
+	}
+	if t.canceled.Load() {
+		return nil, errTaskCanceled
+	}
+	return out, nil
+}
+
+// task is a piece of asynchronous work in flight, such as a
+// Paragon_ForwardBatchAsync submission, registered until it completes.
+type task struct {
+	canceled atomic.Bool
+}
+
+var (
+	tasksMu sync.Mutex
+	tasks   = map[*task]struct{}{}
+
+	errTaskCanceled = fmt.Errorf("task canceled")
+)
+
+func newTask() *task {
+	t := &task{}
+	tasksMu.Lock()
+	tasks[t] = struct{}{}
+	tasksMu.Unlock()
+	return t
+}
+
+func (t *task) done() {
+	tasksMu.Lock()
+	delete(tasks, t)
+	tasksMu.Unlock()
+}
+
+// Paragon_CancelAllTasks cancels every asynchronous task still in flight and
+// returns how many it marked. A forward pass already running cannot be
+// preempted: it finishes, but its result is discarded and the task's
+// callback gets "task canceled" instead.
+//
+//export Paragon_CancelAllTasks
+func Paragon_CancelAllTasks() *C.char {
+	tasksMu.Lock()
+	defer tasksMu.Unlock()
+	n := 0
+	for t := range tasks {
+		if !t.canceled.Swap(true) {
+			n++
+		}
+	}
+	return asJSON(map[string]interface{}{"canceled": n})
+}
+
+// Paragon_EnsembleForward runs one input through every network in the JSON
+// array handlesJSON, concurrently (each under its own handle lock), and
+// combines the outputs by mode: "average" or "max" element-wise, or "vote",
+// where each member votes for its highest output, output holds the fraction
+// of votes per class and class is the winner (lowest index on a tie). All
+// members must accept the input and share the output shape. Returns
+// {"mode","members","output":[...]}, plus "class" and "votes" for "vote".
+//
+//export Paragon_EnsembleForward
+func Paragon_EnsembleForward(handlesJSON *C.char, input *C.float, length C.int, mode *C.char) *C.char {
+	var ids []int64
+	if err := json.Unmarshal([]byte(C.GoString(handlesJSON)), &ids); err != nil {
+		return errJSON(fmt.Sprintf("Invalid JSON input: %v", err))
+	}
+	m := C.GoString(mode)
+	switch {
+	case len(ids) == 0:
+		return errJSON("ensemble has no members")
+	case m != "average" && m != "vote" && m != "max":
+		return errJSON(fmt.Sprintf("unknown mode %q (want average, vote or max)", m))
+	case input == nil:
+		return errJSON("input buffer is NULL")
+	case length < 0:
+		return errJSON(fmt.Sprintf("length must be >= 0, got %d", int(length)))
+	}
+
+	src := unsafe.Slice((*float32)(unsafe.Pointer(input)), int(length))
+	flat := make([]float64, len(src))
+	for i, v := range src {
+		flat[i] = float64(v)
+	}
+
+	type member struct {
+		out   []float64
+		shape [2]int
+		err   error
+	}
+	members := make([]member, len(ids))
+	var wg sync.WaitGroup
+	for i, id := range ids {
+		wg.Add(1)
+		go func(mem *member, id int64) {
+			defer wg.Done()
+			net, unlock, err := lockNetwork(id)
+			if err != nil {
+				mem.err = err
+				return
+			}
+			defer unlock()
+			if err := checkInputShape(net, len(flat)); err != nil {
+				mem.err = err
+				return
+			}
+			touch(id)
+			e, _ := getEntry(id)
+			runForward(e, net, inputGrid(net, flat))
+			out := net.Layers[net.OutputLayer]
+			mem.out, mem.shape = net.GetOutput(), [2]int{out.Width, out.Height}
+		}(&members[i], id)
+	}
+	wg.Wait()
+
+	for i, mem := range members {
+		if mem.err != nil {
+			return errJSON(fmt.Sprintf("handle %d: %v", ids[i], mem.err))
+		}
+		if mem.shape != members[0].shape {
+			return errJSON(fmt.Sprintf("handle %d has output shape %dx%d, handle %d has %dx%d",
+				ids[i], mem.shape[0], mem.shape[1], ids[0], members[0].shape[0], members[0].shape[1]))
+		}
+	}
+
+	n := len(members[0].out)
+	combined := make([]float64, n)
+	result := map[string]interface{}{
+		"mode":    m,
+		"members": len(members),
+	}
+	switch m {
+	case "average":
+		for _, mem := range members {
+			for k, v := range mem.out {
+				combined[k] += v / float64(len(members))
+			}
+		}
+	case "max":
+		copy(combined, members[0].out)
+		for _, mem := range members[1:] {
+			for k, v := range mem.out {
+				combined[k] = math.Max(combined[k], v)
+			}
+		}
+	case "vote":
+		votes := make([]int, n)
+		for _, mem := range members {
+			votes[topK(mem.out, 1)[0]]++
+		}
+		class := 0
+		for k, v := range votes {
+			combined[k] = fraction(v, len(members))
+			if v > votes[class] {
+				class = k
+			}
+		}
+		result["class"] = class
+		result["votes"] = votes
+	}
+	result["output"] = combined
+	return asJSON(result)
+}
+
+// Paragon_ComputeLoss runs a forward pass on one sample and returns its loss
+// against target: "mse" (mean squared error over the output values),
+// "cross_entropy" (-sum target*log(output), as paragon's ComputeLoss),
+// "binary_cross_entropy" (mean over the outputs) or "huber" (delta 1, mean
+// over the outputs).
+//
+//export Paragon_ComputeLoss
+func Paragon_ComputeLoss(handle int64, input *C.float, inLen C.int, target *C.float, tgtLen C.int, lossType *C.char) *C.char {
+	name := C.GoString(lossType)
+	_, loss, err := forwardLoss(handle, input, inLen, target, tgtLen, name)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	return asJSON(map[string]interface{}{
+		"loss":      loss,
+		"loss_type": name,
+	})
+}
+
+// Paragon_ForwardAndLoss is Paragon_ComputeLoss that also returns the output
+// of the forward pass, as {"output":[...],"loss":L,"loss_type":"..."}, so an
+// evaluation loop needs one call per sample.
+//
+//export Paragon_ForwardAndLoss
+func Paragon_ForwardAndLoss(handle int64, input *C.float, inLen C.int, target *C.float, tgtLen C.int, lossType *C.char) *C.char {
+	name := C.GoString(lossType)
+	out, loss, err := forwardLoss(handle, input, inLen, target, tgtLen, name)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	return asJSON(map[string]interface{}{
+		"output":    out,
+		"loss":      loss,
+		"loss_type": name,
+	})
+}
+
+// forwardLoss runs one sample forward and scores the output with the named
+// entry of lossFunctions.
+func forwardLoss(handle int64, input *C.float, inLen C.int, target *C.float, tgtLen C.int, name string) ([]float64, float64, error) {
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer unlock()
+	e, _ := getEntry(handle)
+
+	loss, ok := lossFunctions[name]
+	if !ok {
+		return nil, 0, fmt.Errorf("unknown loss %q (want %s)", name, strings.Join(lossNames(), ", "))
+	}
+	in, err := inputFromC(net, input, inLen)
+	if err != nil {
+		return nil, 0, err
+	}
+	targets, err := targetFromC(net, target, tgtLen)
+	if err != nil {
+		return nil, 0, err
+	}
+	touch(handle)
+
+	runForward(e, net, in)
+	out := net.GetOutput()
+	return out, loss(out, flatGrid(targets)), nil
+}
+
+// Paragon_ForwardTopK runs a forward pass and returns the k highest outputs as
+// {"indices":[...],"scores":[...]}, best first; equal scores keep the lower
+// index first. With Paragon_SetClassNames, "labels" names each index.
+//
+//export Paragon_ForwardTopK
+func Paragon_ForwardTopK(handle int64, input *C.float, length, k C.int) *C.char {
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	defer unlock()
+	e, _ := getEntry(handle)
+
+	in, err := inputFromC(net, input, length)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	out := net.Layers[net.OutputLayer]
+	if n := out.Width * out.Height; k < 1 || int(k) > n {
+		return handleErr(handle, fmt.Sprintf("k=%d out of range for output size %d", int(k), n))
+	}
+	touch(handle)
+
+	runForward(e, net, in)
+	vals := net.GetOutput()
+	indices := topK(vals, int(k))
+	scores := make([]float64, len(indices))
+	for i, idx := range indices {
+		scores[i] = vals[idx]
+	}
+	resp := map[string]interface{}{
+		"indices": indices,
+		"scores":  scores,
+	}
+	if len(e.classNames) == len(vals) {
+		labels := make([]string, len(indices))
+		for i, idx := range indices {
+			labels[i] = e.classNames[idx]
+		}
+		resp["labels"] = labels
+	}
+	return asJSON(resp)
+}
+
+// Paragon_ForwardAndArgmax runs a forward pass and returns the best output as
+// {"class":N,"confidence":P}, where confidence is that output's value (a
+// probability for softmax outputs), plus "label" if class names are set.
+//
+//export Paragon_ForwardAndArgmax
+func Paragon_ForwardAndArgmax(handle int64, input *C.float, length C.int) *C.char {
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	defer unlock()
+	e, _ := getEntry(handle)
+
+	in, err := inputFromC(net, input, length)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	touch(handle)
+
+	runForward(e, net, in)
+	vals := net.GetOutput()
+	class := topK(vals, 1)[0]
+	resp := map[string]interface{}{
+		"class":      class,
+		"confidence": vals[class],
+	}
+	if len(e.classNames) == len(vals) {
+		resp["label"] = e.classNames[class]
+	}
+	return asJSON(resp)
+}
+
+// Paragon_Predict runs a forward pass and returns the output layer's values,
+// or with applySoftmax their softmax, whatever the layer's own activation.
+// The softmax is taken over the activated outputs, so it yields the usual
+// probabilities when the output layer is linear (its values are the logits);
+// an output layer that is already softmax is returned as is rather than
+// normalized twice. Free the buffer with Paragon_FreeFloatBuffer; returns
+// NULL on failure.
+//
+//export Paragon_Predict
+func Paragon_Predict(handle int64, input *C.float, length C.int, applySoftmax C.bool) *C.float {
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		setHandleError(handle, err.Error())
+		return nil
+	}
+	defer unlock()
+	e, _ := getEntry(handle)
+
+	in, err := inputFromC(net, input, length)
+	if err != nil {
+		setHandleError(handle, err.Error())
+		return nil
+	}
+	touch(handle)
+
+	runForward(e, net, in)
+	out := net.GetOutput()
+	if applySoftmax && net.Layers[net.OutputLayer].Neurons[0][0].Activation != "softmax" {
+		out = softmaxTemperature(out, 1)
+	}
+	return floatBuf(out)
+}
+
+// Paragon_SetClassNames labels the output neurons, in row-major order, with a
+// JSON array of strings whose length must equal the output size; null or []
+// clears them. Paragon_ReplaceOutputLayer also clears them; after any other
+// change to the output size they are kept but no longer reported as labels.
+//
+//export Paragon_SetClassNames
+func Paragon_SetClassNames(handle int64, namesJSON *C.char) *C.char {
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	defer unlock()
+	e, _ := getEntry(handle)
+
+	var names []string
+	if err := json.Unmarshal([]byte(C.GoString(namesJSON)), &names); err != nil {
+		return handleErr(handle, "invalid class names: "+err.Error())
+	}
+	out := net.Layers[net.OutputLayer]
+	if n := out.Width * out.Height; len(names) != 0 && len(names) != n {
+		return handleErr(handle, fmt.Sprintf("got %d class names, output size is %d", len(names), n))
+	}
+	touch(handle)
+
+	if len(names) == 0 {
+		names = nil
+	}
+	e.classNames = names
+	return asJSON(map[string]interface{}{"handle": handle, "names": names})
+}
+
+// Paragon_GetClassNames returns the labels set by Paragon_SetClassNames as
+// {"names":[...]}, or {"names":null} if none are set.
+//
+//export Paragon_GetClassNames
+func Paragon_GetClassNames(handle int64) *C.char {
+	_, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	defer unlock()
+	e, _ := getEntry(handle)
+	return asJSON(map[string]interface{}{"handle": handle, "names": e.classNames})
+}
+
+// Paragon_GetLastForwardInput returns the input of the most recent forward
+// export on this handle exactly as fed to the network, storing its length in
+// outLen. Reflected Paragon_Call("Forward") calls are not recorded. Free the
+// buffer with Paragon_FreeFloatBuffer. Returns NULL if no forward has run.
+//
+//export Paragon_GetLastForwardInput
+func Paragon_GetLastForwardInput(handle int64, outLen *C.int) *C.float {
+	_, unlock, err := lockNetwork(handle)
+	if err != nil {
+		setHandleError(handle, err.Error())
+		return nil
+	}
+	defer unlock()
+	e, _ := getEntry(handle)
+
+	if e.lastInput == nil {
+		setHandleError(handle, "no forward pass has run on this handle")
+		return nil
+	}
+	if outLen != nil {
+		*outLen = C.int(len(e.lastInput))
+	}
+	return floatBuf(e.lastInput)
+}
+
+// Paragon_TraceForward runs a forward pass and returns every layer's
+// activations, input layer included, as {"layers":[{"index","width",
+// "height","values":[[...]]}],"total_values":N}. The response holds one JSON
+// number per neuron in the network (roughly 20 bytes each), so prefer
+// Paragon_GetActivationStatistics or a single layer for large models. On the
+// GPU the hidden layers are recomputed on the CPU, since only the output comes
+// back.
+//
+//export Paragon_TraceForward
+func Paragon_TraceForward(handle int64, input *C.float, length C.int) *C.char {
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	defer unlock()
+	e, _ := getEntry(handle)
+
+	in, err := inputFromC(net, input, length)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	touch(handle)
+
+	runForward(e, net, in)
+	if net.WebGPUNative {
+		forwardCPU(net, e.actParams, in, nil)
+	}
+
+	layers := make([]map[string]interface{}, len(net.Layers))
+	total := 0
+	for l, layer := range net.Layers {
+		values := make([][]float32, layer.Height)
+		for y, row := range layer.Neurons {
+			values[y] = make([]float32, layer.Width)
+			for x, neuron := range row {
+				values[y][x] = neuron.Value
+			}
+		}
+		total += layer.Width * layer.Height
+		layers[l] = map[string]interface{}{
+			"index":  l,
+			"width":  layer.Width,
+			"height": layer.Height,
+			"values": values,
+		}
+	}
+	return asJSON(map[string]interface{}{
+		"layers":       layers,
+		"total_values": total,
+	})
+}
+
+// Paragon_SoftmaxOutputWithTemperature returns softmax(logits / temperature)
+// over the output of the last forward pass. If the output layer is already
+// softmax, its log-probabilities serve as the logits, which gives the same
+// distribution. Free the buffer with Paragon_FreeFloatBuffer; returns NULL on
+// failure.
+//
+//export Paragon_SoftmaxOutputWithTemperature
+func Paragon_SoftmaxOutputWithTemperature(handle int64, temperature C.double) *C.float {
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		setHandleError(handle, err.Error())
+		return nil
+	}
+	defer unlock()
+	e, _ := getEntry(handle)
+	probs, err := temperedOutput(e, net, float64(temperature))
+	if err != nil {
+		setHandleError(handle, err.Error())
+		return nil
+	}
+	touch(handle)
+
+	return floatBuf(probs)
+}
+
+// Paragon_SampleOutput draws one output index from the temperature-scaled
+// softmax of the last forward output; the same seed always draws the same
+// index from the same distribution.
+//
+//export Paragon_SampleOutput
+func Paragon_SampleOutput(handle int64, temperature C.double, seed int64) *C.char {
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	defer unlock()
+	e, _ := getEntry(handle)
+	probs, err := temperedOutput(e, net, float64(temperature))
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	touch(handle)
+
+	r := newRNG(seed).Float64()
+	idx := len(probs) - 1
+	for i, p := range probs {
+		if r < p {
+			idx = i
+			break
+		}
+		r -= p
+	}
+	return asJSON(map[string]interface{}{
+		"sampled":     idx,
+		"probability": probs[idx],
+	})
+}
+
+// temperedOutput is the shared body of Paragon_SoftmaxOutputWithTemperature
+// and Paragon_SampleOutput.
+func temperedOutput(e *entry, net *paragon.Network[float32], temperature float64) ([]float64, error) {
+	if e.lastInput == nil {
+		return nil, fmt.Errorf("no forward pass has run on this handle")
+	}
+	if !(temperature > 0) {
+		return nil, fmt.Errorf("temperature must be > 0, got %v", temperature)
+	}
+
+	logits := net.GetOutput()
+	if net.Layers[net.OutputLayer].Neurons[0][0].Activation == "softmax" {
+		for i, p := range logits {
+			logits[i] = math.Log(p)
+		}
+	}
+	return softmaxTemperature(logits, temperature), nil
+}
+
+// Paragon_GetActivationStatistics summarizes each non-input layer's
+// activations from the last forward export on this handle: mean, std, the
+// fraction of exactly-zero units (dead ReLUs) and, for sigmoid and tanh
+// layers, the fraction within 0.01 of an asymptote (null for other
+// activations). On the GPU only output values come back, so hidden layers are
+// recomputed on the CPU from the recorded input.
+//
+//export Paragon_GetActivationStatistics
+func Paragon_GetActivationStatistics(handle int64) *C.char {
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	defer unlock()
+	e, _ := getEntry(handle)
+	if e.lastInput == nil {
+		return handleErr(handle, "no forward pass has run on this handle")
+	}
+	touch(handle)
+
+	if net.WebGPUNative {
+		forwardCPU(net, e.actParams, inputGrid(net, e.lastInput), nil)
+	}
+
+	layers := make([]map[string]interface{}, 0, len(net.Layers)-net.InputLayer-1)
+	for l := net.InputLayer + 1; l < len(net.Layers); l++ {
+		layer := net.Layers[l]
+		act := layer.Neurons[0][0].Activation
+		var sum, sumSq float64
+		dead, saturated, n := 0, 0, layer.Width*layer.Height
+		for _, row := range layer.Neurons {
+			for _, neuron := range row {
+				v := float64(neuron.Value)
+				sum += v
+				sumSq += v * v
+				if v == 0 {
+					dead++
+				}
+				switch act {
+				case "sigmoid":
+					if v <= 0.01 || v >= 0.99 {
+						saturated++
+					}
+				case "tanh":
+					if math.Abs(v) >= 0.99 {
+						saturated++
+					}
+				}
+			}
+		}
+		mean := sum / float64(n)
+		var saturation interface{}
+		if act == "sigmoid" || act == "tanh" {
+			saturation = float64(saturated) / float64(n)
+		}
+		layers = append(layers, map[string]interface{}{
+			"index":               l,
+			"activation":          act,
+			"mean":                mean,
+			"std":                 math.Sqrt(math.Max(sumSq/float64(n)-mean*mean, 0)),
+			"dead_fraction":       float64(dead) / float64(n),
+			"saturation_fraction": saturation,
+		})
+	}
+	return asJSON(map[string]interface{}{"layers": layers})
+}
+
+// Paragon_GetSaturationReport reports, for each non-input layer and the last
+// forward export on this handle, the fraction of activations below
+// lowThreshold and above highThreshold and their sum, e.g. thresholds 0.01 and
+// 0.99 for sigmoid or -0.99 and 0.99 for tanh. As in
+// Paragon_GetActivationStatistics, a GPU handle's hidden layers are recomputed
+// on the CPU from the recorded input.
+//
+//export Paragon_GetSaturationReport
+func Paragon_GetSaturationReport(handle int64, lowThreshold, highThreshold C.double) *C.char {
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	defer unlock()
+	lo, hi := float64(lowThreshold), float64(highThreshold)
+	if !(lo <= hi) {
+		return handleErr(handle, fmt.Sprintf("low threshold %v must not exceed high threshold %v", lo, hi))
+	}
+	e, _ := getEntry(handle)
+	if e.lastInput == nil {
+		return handleErr(handle, "no forward pass has run on this handle")
+	}
+	touch(handle)
+
+	if net.WebGPUNative {
+		forwardCPU(net, e.actParams, inputGrid(net, e.lastInput), nil)
+	}
+
+	layers := make([]map[string]interface{}, 0, len(net.Layers)-net.InputLayer-1)
+	for l := net.InputLayer + 1; l < len(net.Layers); l++ {
+		layer := net.Layers[l]
+		below, above, n := 0, 0, layer.Width*layer.Height
+		for _, row := range layer.Neurons {
+			for _, neuron := range row {
+				v := float64(neuron.Value)
+				if v < lo {
+					below++
+				} else if v > hi {
+					above++
+				}
+			}
+		}
+		layers = append(layers, map[string]interface{}{
+			"index":               l,
+			"activation":          layer.Neurons[0][0].Activation,
+			"below_fraction":      fraction(below, n),
+			"above_fraction":      fraction(above, n),
+			"saturation_fraction": fraction(below+above, n),
+		})
+	}
+	return asJSON(map[string]interface{}{
+		"low_threshold":  lo,
+		"high_threshold": hi,
+		"layers":         layers,
+	})
+}
+
+// sliceStream is a slice result from Paragon_CallSliceStream, handed out in
+// chunks by Paragon_SliceStreamNext.
+type sliceStream struct {
+	vals reflect.Value
+	next int
+}
+
+// Paragon_CallSliceStream invokes method like Paragon_Call but, instead of
+// encoding its slice result in one string, registers it as a stream and
+// returns {"stream": id, "length": n}. Read it with Paragon_SliceStreamNext
+// and release it with Paragon_SliceStreamClose (or Paragon_Free).
+//
+//export Paragon_CallSliceStream
+func Paragon_CallSliceStream(handle int64, method *C.char, argsJSON *C.char) *C.char {
+	e, ok := getEntry(handle)
+	if !ok {
+		return handleErr(handle, fmt.Sprintf("invalid handle %d", handle))
+	}
+	touch(handle)
+
+	methodName := C.GoString(method)
+	m := reflect.ValueOf(e.obj).MethodByName(methodName)
+	if !m.IsValid() {
+		return handleErr(handle, "Method not found: "+methodName)
+	}
+	params, err := parseParams(C.GoString(argsJSON))
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	in, err := convertArgs(m.Type(), params)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+
+	out, err := func() ([]reflect.Value, error) {
+		runCallHook(handle, methodName, "before")
+		defer runCallHook(handle, methodName, "after")
+		defer lockForCall(e, methodName)()
+		if strings.HasPrefix(methodName, "Forward") {
+			e.forwards.Add(1)
+		}
+		return invoke(m, in)
+	}()
+	if err == nil {
+		err = returnedError(out)
+	}
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	if len(out) == 0 || out[0].Kind() != reflect.Slice {
+		return handleErr(handle, fmt.Sprintf("%s does not return a slice", methodName))
+	}
+
+	id, ok := putIn(namespaceOf(handle), &sliceStream{vals: out[0]})
+	if !ok {
+		return handleErr(handle, fmt.Sprintf("namespace %d was freed", namespaceOf(handle)))
+	}
+	return asJSON(map[string]interface{}{"stream": id, "length": out[0].Len()})
+}
+
+// Paragon_SliceStreamNext returns the next count elements of a stream as
+// {"items": [...], "remaining": n, "done": bool}. Once done, further calls
+// return an empty items array.
+//
+//export Paragon_SliceStreamNext
+func Paragon_SliceStreamNext(streamID int64, count C.int) *C.char {
+	e, ok := getEntry(streamID)
+	if !ok {
+		return handleErr(streamID, fmt.Sprintf("invalid handle %d", streamID))
+	}
+	s, ok := e.obj.(*sliceStream)
+	if !ok {
+		return handleErr(streamID, fmt.Sprintf("handle %d is not a slice stream", streamID))
+	}
+	if count <= 0 {
+		return handleErr(streamID, fmt.Sprintf("count must be positive, got %d", int(count)))
+	}
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	touch(streamID)
+
+	end := s.next + int(count)
+	if n := s.vals.Len(); end > n {
+		end = n
+	}
+	items := make([]interface{}, 0, end-s.next)
+	for i := s.next; i < end; i++ {
+		items = append(items, s.vals.Index(i).Interface())
+	}
+	s.next = end
+	remaining := s.vals.Len() - end
+	return asJSON(map[string]interface{}{
+		"items":     items,
+		"remaining": remaining,
+		"done":      remaining == 0,
+	})
+}
+
+// Paragon_SliceStreamClose releases a stream created by
+// Paragon_CallSliceStream.
+//
+//export Paragon_SliceStreamClose
+func Paragon_SliceStreamClose(streamID int64) *C.char {
+	e, ok := getEntry(streamID)
+	if !ok {
+		return handleErr(streamID, fmt.Sprintf("invalid handle %d", streamID))
+	}
+	if _, ok := e.obj.(*sliceStream); !ok {
+		return handleErr(streamID, fmt.Sprintf("handle %d is not a slice stream", streamID))
+	}
+	freeHandle(streamID)
+	return asJSON(map[string]interface{}{"closed": streamID})
+}
+
+// Paragon_StartCalibration discards any previous ranges and makes the
+// handle's forward exports and reflected Forward calls record each layer's
+// activation min/max until Paragon_StopCalibration. While calibrating those
+// passes run on the CPU, even on a GPU-enabled handle, so that hidden layers
+// are observable.
+//
+//export Paragon_StartCalibration
+func Paragon_StartCalibration(handle int64) *C.char {
+	_, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	defer unlock()
+
+	e, _ := getEntry(handle)
+	e.calib = &calibration{active: true}
+	return asJSON(map[string]interface{}{"status": "calibrating"})
+}
+
+// Paragon_StopCalibration stops recording; the ranges collected so far stay
+// available from Paragon_GetCalibrationRanges.
+//
+//export Paragon_StopCalibration
+func Paragon_StopCalibration(handle int64) *C.char {
+	_, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	defer unlock()
+
+	e, _ := getEntry(handle)
+	if e.calib == nil || !e.calib.active {
+		return handleErr(handle, "calibration is not running")
+	}
+	e.calib.active = false
+	return asJSON(map[string]interface{}{
+		"status":  "calibration stopped",
+		"samples": e.calib.samples,
+	})
+}
+
+// Paragon_GetCalibrationRanges returns the activation range of every layer
+// (input layer included) over the forward passes recorded since the last
+// Paragon_StartCalibration, as {"samples":N,"calibrating":bool,"layers":
+// [{"index","min","max"},...]}. It fails if no pass has been recorded.
+//
+//export Paragon_GetCalibrationRanges
+func Paragon_GetCalibrationRanges(handle int64) *C.char {
+	_, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	defer unlock()
+
+	e, _ := getEntry(handle)
+	if e.calib == nil {
+		return handleErr(handle, "calibration has not been started")
+	}
+	if e.calib.samples == 0 {
+		return handleErr(handle, "no forward pass recorded since calibration started")
+	}
+	layers := make([]map[string]interface{}, len(e.calib.min))
+	for l := range layers {
+		layers[l] = map[string]interface{}{
+			"index": l,
+			"min":   e.calib.min[l],
+			"max":   e.calib.max[l],
+		}
+	}
+	return asJSON(map[string]interface{}{
+		"samples":     e.calib.samples,
+		"calibrating": e.calib.active,
+		"layers":      layers,
+	})
+}
+
+// Paragon_SetRandomBackend selects the generator behind every seeded bridge
+// export (Paragon_ReinitializeWeights, Paragon_PerturbWeights,
+// Paragon_ForwardWithDropout, Paragon_SampleOutput) and behind network
+// construction after Paragon_SetGlobalSeed: "go" (default), "pcg" or
+// "mt19937". All three are pure Go, so a backend and seed give the same
+// sequence on every platform. It applies process-wide; reflected methods
+// called through Paragon_Call use paragon's own generators and are not
+// affected.
+//
+//export Paragon_SetRandomBackend
+func Paragon_SetRandomBackend(name *C.char) *C.char {
+	backend := C.GoString(name)
+	if _, ok := rngBackends[backend]; !ok {
+		return errJSON(fmt.Sprintf("unknown random backend %q (want go, pcg or mt19937)", backend))
+	}
+	rngMu.Lock()
+	defer rngMu.Unlock()
+	rngBackend = backend
+	return asJSON(map[string]string{"backend": backend})
+}
+
+// Paragon_SetGlobalSeed makes network construction reproducible: the n-th
+// network built after the call (n counting from 0) has its weights drawn
+// from U(-1, 1), paragon's constructor default, by the selected random
+// backend seeded with seed+n. Exports that take a seed argument always use
+// that argument instead; there is no separate per-handle seed.
+//
+//export Paragon_SetGlobalSeed
+func Paragon_SetGlobalSeed(seed int64) *C.char {
+	rngMu.Lock()
+	defer rngMu.Unlock()
+	globalSeed, globalSeeded, networkSeeds = seed, true, 0
+	return asJSON(map[string]interface{}{"seed": seed})
+}
+
+//export Paragon_GetLastError
+func Paragon_GetLastError() *C.char {
+	errMu.Lock()
+	defer errMu.Unlock()
+	return asJSON(map[string]string{"last_error": lastErr})
+}
+
+// Paragon_GetHandleError returns the most recent failure of any export or
+// call on handle as {"handle":ID,"error":"...","time":"..."}, with an empty
+// error (and no time) if nothing has failed on it.
+//
+//export Paragon_GetHandleError
+func Paragon_GetHandleError(handle int64) *C.char {
+	e, ok := getEntry(handle)
+	if !ok {
+		return handleErr(handle, fmt.Sprintf("invalid handle %d", handle))
+	}
+	errMu.Lock()
+	defer errMu.Unlock()
+	resp := map[string]interface{}{
+		"handle": handle,
+		"error":  e.lastErr,
+	}
+	if e.lastErr != "" {
+		resp["time"] = e.lastErrTime
+	}
+	return asJSON(resp)
+}
+
+// Paragon_GetErrorHistory returns the most recent bridge errors, oldest
+// first, as a JSON array of {"time","code","export","message","handle"}. Only
+// the last 64 are kept.
+//
+//export Paragon_GetErrorHistory
+func Paragon_GetErrorHistory() *C.char {
+	histMu.Lock()
+	defer histMu.Unlock()
+	out := make([]errorRecord, 0, histLen)
+	for i := 0; i < histLen; i++ {
+		out = append(out, history[(histNext-histLen+i+errorHistorySize)%errorHistorySize])
+	}
+	return asJSON(out)
+}
+
+//export Paragon_ClearErrorHistory
+func Paragon_ClearErrorHistory() {
+	histMu.Lock()
+	defer histMu.Unlock()
+	history = [errorHistorySize]errorRecord{}
+	histNext, histLen = 0, 0
+}
+
+// Paragon_ReinitializeWeights redraws every weight in place from seed and
+// zeroes the biases. Schemes, with fan-in/fan-out taken per neuron/layer:
+//
+//	"xavier"  U(-a, a), a = sqrt(6 / (fan_in + fan_out))
+//	"he"      N(0, sqrt(2 / fan_in))
+//	"uniform" U(-1, 1), paragon's constructor default
+//	"normal"  N(0, 1)
+//
+// A GPU-enabled handle stays on the GPU with the new weights.
+//
+//export Paragon_ReinitializeWeights
+func Paragon_ReinitializeWeights(handle int64, scheme *C.char, seed int64) *C.char {
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	defer unlock()
+
+	name := C.GoString(scheme)
+	rng := newRNG(seed)
+	var draw func(fanIn, fanOut int) float64
+	switch name {
+	case "xavier":
+		draw = func(fanIn, fanOut int) float64 {
+			a := math.Sqrt(6 / float64(fanIn+fanOut))
+			return (rng.Float64()*2 - 1) * a
+		}
+	case "he":
+		draw = func(fanIn, _ int) float64 {
+			return rng.NormFloat64() * math.Sqrt(2/float64(fanIn))
+		}
+	case "uniform":
+		draw = func(_, _ int) float64 { return rng.Float64()*2 - 1 }
+	case "normal":
+		draw = func(_, _ int) float64 { return rng.NormFloat64() }
+	default:
+		return handleErr(handle, "unknown init scheme: "+name+" (want xavier, he, uniform or normal)")
+	}
+
+	redrawWeights(net, draw)
+	if err := syncToGPU(net); err != nil {
+		return handleErr(handle, err.Error())
+	}
+	return asJSON(map[string]interface{}{
+		"status": "weights reinitialized",
+		"scheme": name,
+		"seed":   seed,
+	})
+}
+
+// redrawWeights sets every weight of net to draw(fan_in, fan_out) and zeroes
+// the biases.
+func redrawWeights(net *paragon.Network[float32], draw func(fanIn, fanOut int) float64) {
+	for l := net.InputLayer + 1; l < len(net.Layers); l++ {
+		layer := net.Layers[l]
+		fanOut := layer.Width * layer.Height
+		for _, row := range layer.Neurons {
+			for _, neuron := range row {
+				fanIn := len(neuron.Inputs)
+				for k := range neuron.Inputs {
+					neuron.Inputs[k].Weight = float32(draw(fanIn, fanOut))
+				}
+				neuron.Bias = 0
+			}
+		}
+	}
+}
+
+// Paragon_ExportWeights returns every weight and bias in the bridge's flat
+// layout (see flatWeights) and stores its length in outLen. Free the buffer
+// with Paragon_FreeFloatBuffer. Returns NULL on failure.
+//
+//export Paragon_ExportWeights
+func Paragon_ExportWeights(handle int64, outLen *C.int) *C.float {
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		setHandleError(handle, err.Error())
+		return nil
+	}
+	defer unlock()
+
+	vals := flatWeights(net)
+	if outLen != nil {
+		*outLen = C.int(len(vals))
+	}
+	return floatBuf(vals)
+}
+
+// Paragon_ImportWeights loads length values in the Paragon_ExportWeights
+// layout into the network, replacing every weight and bias. The length must
+// match the network's parameter count exactly; nothing is loaded otherwise.
+//
+//export Paragon_ImportWeights
+func Paragon_ImportWeights(handle int64, data *C.float, length C.int) *C.char {
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	defer unlock()
+	touch(handle)
+	switch {
+	case data == nil:
+		return handleErr(handle, "weight buffer is NULL")
+	case length < 0:
+		return handleErr(handle, fmt.Sprintf("length must be >= 0, got %d", int(length)))
+	}
+
+	vals := unsafe.Slice((*float32)(unsafe.Pointer(data)), int(length))
+	if err := loadFlatWeights(net, vals); err != nil {
+		return handleErr(handle, err.Error())
+	}
+	if err := syncToGPU(net); err != nil {
+		return handleErr(handle, err.Error())
+	}
+	return asJSON(map[string]interface{}{
+		"status": "weights imported",
+		"count":  len(vals),
+	})
+}
+
+// Paragon_ImportWeightsRemap loads selected layers from another model's flat
+// weights (the Paragon_ExportWeights layout) into differently numbered
+// layers of this one. layerMapJSON is
+// {"map":{"<source layer>":<target layer>,...},"source_layers":[[rows,cols],...]}
+// where source_layers gives the source model's weight-matrix shapes (as
+// Paragon_GetLayerWeights reports them) for its layers 1, 2, ... so data can
+// be split per layer. Each mapped pair must have the same shape; unmapped
+// layers are left untouched, and nothing is loaded if any check fails.
+//
+//export Paragon_ImportWeightsRemap
+func Paragon_ImportWeightsRemap(handle int64, data *C.float, length C.int, layerMapJSON *C.char) *C.char {
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	defer unlock()
+
+	var spec struct {
+		Map          map[string]int `json:"map"`
+		SourceLayers [][2]int       `json:"source_layers"`
+	}
+	if err := json.Unmarshal([]byte(C.GoString(layerMapJSON)), &spec); err != nil {
+		return handleErr(handle, fmt.Sprintf("invalid layer map JSON: %v", err))
+	}
+	if len(spec.Map) == 0 {
+		return handleErr(handle, "layer map is empty")
+	}
+
+	// offsets[i] is where source layer i+1 starts in data.
+	offsets := make([]int, len(spec.SourceLayers))
+	total := 0
+	for i, shape := range spec.SourceLayers {
+		if shape[0] <= 0 || shape[1] < 0 {
+			return handleErr(handle, fmt.Sprintf("source layer %d has invalid shape %dx%d", i+1, shape[0], shape[1]))
+		}
+		offsets[i] = total
+		total += shape[0]*shape[1] + shape[0]
+	}
+	if int(length) != total {
+		return handleErr(handle, fmt.Sprintf("source layers hold %d parameters, got %d", total, int(length)))
+	}
+	if data == nil {
+		return handleErr(handle, "weight buffer is NULL")
+	}
+
+	type remap struct{ src, dst int }
+	pairs := make([]remap, 0, len(spec.Map))
+	keys := make([]string, 0, len(spec.Map))
+	for key := range spec.Map {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	targets := map[int]int{}
+	for _, key := range keys {
+		dst := spec.Map[key]
+		src, err := strconv.Atoi(key)
+		if err != nil || src < 1 || src > len(spec.SourceLayers) {
+			return handleErr(handle, fmt.Sprintf("source layer %q out of range (1..%d)", key, len(spec.SourceLayers)))
+		}
+		if prev, ok := targets[dst]; ok {
+			return handleErr(handle, fmt.Sprintf("target layer %d is mapped from both source layers %d and %d", dst, prev, src))
+		}
+		targets[dst] = src
+		r, c, err := layerWeightShape(net, dst)
+		if err != nil {
+			return handleErr(handle, err.Error())
+		}
+		if shape := spec.SourceLayers[src-1]; shape != [2]int{r, c} {
+			return handleErr(handle, fmt.Sprintf("source layer %d is %dx%d but target layer %d is %dx%d", src, shape[0], shape[1], dst, r, c))
+		}
+		pairs = append(pairs, remap{src, dst})
+	}
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].dst < pairs[j].dst })
+
+	vals := unsafe.Slice((*float32)(unsafe.Pointer(data)), int(length))
+	loaded := make([]map[string]int, len(pairs))
+	for n, p := range pairs {
+		i := offsets[p.src-1]
+		layer := net.Layers[p.dst]
+		for _, row := range layer.Neurons {
+			for _, neuron := range row {
+				for k := range neuron.Inputs {
+					neuron.Inputs[k].Weight = vals[i]
+					i++
+				}
+			}
+		}
+		for _, row := range layer.Neurons {
+			for _, neuron := range row {
+				neuron.Bias = vals[i]
+				i++
+			}
+		}
+		loaded[n] = map[string]int{"source": p.src, "target": p.dst}
+	}
+
+	if err := syncToGPU(net); err != nil {
+		return handleErr(handle, err.Error())
+	}
+	return asJSON(map[string]interface{}{
+		"status": "weights imported",
+		"layers": loaded,
+	})
+}
+
+// Paragon_GetLayerParameterRange locates one layer's parameters in the flat
+// layout of Paragon_ExportWeights: count values from start, the layer's
+// weights first and its biases from bias_start on.
+//
+//export Paragon_GetLayerParameterRange
+func Paragon_GetLayerParameterRange(handle int64, layerIndex C.int) *C.char {
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	defer unlock()
+
+	target := int(layerIndex)
+	if target <= net.InputLayer || target >= len(net.Layers) {
+		return handleErr(handle, fmt.Sprintf("layer index %d out of range (%d..%d)", target, net.InputLayer+1, len(net.Layers)-1))
+	}
+	start := 0
+	for l := net.InputLayer + 1; ; l++ {
+		weights, biases := 0, 0
+		for _, row := range net.Layers[l].Neurons {
+			for _, neuron := range row {
+				weights += len(neuron.Inputs)
+				biases++
+			}
+		}
+		if l == target {
+			return asJSON(map[string]interface{}{
+				"layer":      l,
+				"start":      start,
+				"count":      weights + biases,
+				"bias_start": start + weights,
+			})
+		}
+		start += weights + biases
+	}
+}
+
+// Paragon_SnapshotToBuffer captures the network's weights and biases as a
+// caller-owned buffer in the flat layout of Paragon_ExportWeights (no header,
+// four bytes per parameter), storing its length in outLen, for hosts that
+// cache snapshots themselves. Free it with Paragon_FreeFloatBuffer. Returns
+// NULL on failure.
+//
+//export Paragon_SnapshotToBuffer
+func Paragon_SnapshotToBuffer(handle int64, outLen *C.int) *C.float {
+	return Paragon_ExportWeights(handle, outLen)
+}
+
+// Paragon_RestoreFromBuffer restores a buffer from Paragon_SnapshotToBuffer
+// into a network of the same architecture; a buffer of the wrong length is
+// rejected without changing anything. A GPU-enabled handle stays on the GPU
+// with the restored weights.
+//
+//export Paragon_RestoreFromBuffer
+func Paragon_RestoreFromBuffer(handle int64, data *C.float, length C.int) *C.char {
+	return Paragon_ImportWeights(handle, data, length)
+}
+
+// Paragon_ExportWeightsBase64 is Paragon_ExportWeights for string-only FFI
+// bridges: the same flat layout as little-endian float32, base64-encoded.
+//
+//export Paragon_ExportWeightsBase64
+func Paragon_ExportWeightsBase64(handle int64) *C.char {
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	defer unlock()
+
+	vals := flatWeights(net)
+	raw := make([]byte, 4*len(vals))
+	for i, v := range vals {
+		binary.LittleEndian.PutUint32(raw[4*i:], math.Float32bits(float32(v)))
+	}
+	return asJSON(map[string]interface{}{
+		"data":  base64.StdEncoding.EncodeToString(raw),
+		"count": len(vals),
+	})
+}
+
+//export Paragon_ImportWeightsBase64
+func Paragon_ImportWeightsBase64(handle int64, data *C.char) *C.char {
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	defer unlock()
+
+	raw, err := base64.StdEncoding.DecodeString(C.GoString(data))
+	if err != nil {
+		return handleErr(handle, "base64: "+err.Error())
+	}
+	if len(raw)%4 != 0 {
+		return handleErr(handle, fmt.Sprintf("decoded length %d is not a multiple of 4 bytes", len(raw)))
+	}
+	vals := make([]float32, len(raw)/4)
+	for i := range vals {
+		vals[i] = math.Float32frombits(binary.LittleEndian.Uint32(raw[4*i:]))
+	}
+	if err := loadFlatWeights(net, vals); err != nil {
+		return handleErr(handle, err.Error())
+	}
+	if err := syncToGPU(net); err != nil {
+		return handleErr(handle, err.Error())
+	}
+	return asJSON(map[string]interface{}{
+		"status": "weights imported",
+		"count":  len(vals),
+	})
+}
+
+// Paragon_ExportWeightsNPY writes every layer after the input as two NumPy
+// .npy files in dir (created if missing): layer<i>_weights.npy, the
+// [neurons x fan-in] matrix of Paragon_GetLayerWeights, and
+// layer<i>_biases.npy. The dtype follows the handle: float32, float64 or int8
+// for Paragon_Quantize handles (raw quantized values). Returns the paths
+// written; on failure, files already written are kept and listed.
+//
+//export Paragon_ExportWeightsNPY
+func Paragon_ExportWeightsNPY(handle int64, dir *C.char) *C.char {
+	e, ok := getEntry(handle)
+	if !ok {
+		return handleErr(handle, fmt.Sprintf("invalid handle %d", handle))
+	}
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+
+	path := C.GoString(dir)
+	if err := os.MkdirAll(path, 0o755); err != nil {
+		return handleErr(handle, err.Error())
+	}
+	var (
+		files []string
+		dtype string
+		err   error
+	)
+	switch net := e.obj.(type) {
+	case *paragon.Network[float32]:
+		files, err = exportNPY(net, path)
+		dtype = "float32"
+	case *paragon.Network[float64]:
+		files, err = exportNPY(net, path)
+		dtype = "float64"
+	case *paragon.Network[int8]:
+		files, err = exportNPY(net, path)
+		dtype = "int8"
+	default:
+		return handleErr(handle, fmt.Sprintf("%T is not a network", e.obj))
+	}
+	if err != nil {
+		recordError(handle, "error", err.Error())
+		return asJSON(map[string]interface{}{
+			"error": err.Error(),
+			"files": files,
+		})
+	}
+	return asJSON(map[string]interface{}{
+		"files": files,
+		"dtype": dtype,
+	})
+}
+
+// Paragon_ImportWeightsNPY loads the files Paragon_ExportWeightsNPY writes
+// (or NumPy arrays saved under the same names) from dir. Each file's dtype
+// must match the handle's element type and its shape the layer's; on any
+// mismatch nothing is loaded and the error names the file. A GPU-enabled
+// handle stays on the GPU with the new weights.
+//
+//export Paragon_ImportWeightsNPY
+func Paragon_ImportWeightsNPY(handle int64, dir *C.char) *C.char {
+	e, ok := getEntry(handle)
+	if !ok {
+		return handleErr(handle, fmt.Sprintf("invalid handle %d", handle))
+	}
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	path := C.GoString(dir)
+	var (
+		files []string
+		err   error
+	)
+	switch net := e.obj.(type) {
+	case *paragon.Network[float32]:
+		if files, err = importNPY(net, path); err == nil {
+			err = syncToGPU(net)
+		}
+	case *paragon.Network[float64]:
+		files, err = importNPY(net, path)
+	case *paragon.Network[int8]:
+		files, err = importNPY(net, path)
+	default:
+		return handleErr(handle, fmt.Sprintf("%T is not a network", e.obj))
+	}
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	return asJSON(map[string]interface{}{
+		"status": "weights imported",
+		"files":  files,
+	})
+}
+
+// Paragon_GetLayerWeights returns one layer's weight matrix (biases excluded)
+// row-major as [neurons x fan-in], storing the shape in rows and cols. Free
+// the buffer with Paragon_FreeFloatBuffer. Returns NULL on failure.
+//
+//export Paragon_GetLayerWeights
+func Paragon_GetLayerWeights(handle int64, layerIndex C.int, rows, cols *C.int) *C.float {
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		setHandleError(handle, err.Error())
+		return nil
+	}
+	defer unlock()
+
+	r, c, err := layerWeightShape(net, int(layerIndex))
+	if err != nil {
+		setHandleError(handle, err.Error())
+		return nil
+	}
+	vals := make([]float64, 0, r*c)
+	for _, row := range net.Layers[int(layerIndex)].Neurons {
+		for _, neuron := range row {
+			for _, conn := range neuron.Inputs {
+				vals = append(vals, float64(conn.Weight))
+			}
+		}
+	}
+	if rows != nil {
+		*rows = C.int(r)
+	}
+	if cols != nil {
+		*cols = C.int(c)
+	}
+	return floatBuf(vals)
+}
+
+//export Paragon_SetLayerWeights
+func Paragon_SetLayerWeights(handle int64, layerIndex C.int, data *C.float, length C.int) *C.char {
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	defer unlock()
+
+	r, c, err := layerWeightShape(net, int(layerIndex))
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	if int(length) != r*c {
+		return handleErr(handle, fmt.Sprintf("layer %d expects %dx%d = %d weights, got %d", int(layerIndex), r, c, r*c, int(length)))
+	}
+	if data == nil {
+		return handleErr(handle, "weight buffer is NULL")
+	}
+
+	vals := unsafe.Slice((*float32)(unsafe.Pointer(data)), int(length))
+	i := 0
+	for _, row := range net.Layers[int(layerIndex)].Neurons {
+		for _, neuron := range row {
+			for k := range neuron.Inputs {
+				neuron.Inputs[k].Weight = vals[i]
+				i++
+			}
+		}
+	}
+	if err := syncToGPU(net); err != nil {
+		return handleErr(handle, err.Error())
+	}
+	return asJSON(map[string]interface{}{
+		"status": "layer weights set",
+		"layer":  int(layerIndex),
+		"rows":   r,
+		"cols":   c,
+	})
+}
+
+// Paragon_SetLayerWeightsJSON is Paragon_SetLayerWeights for hosts without
+// float buffers: matrixJSON is the [neurons][fan-in] weight matrix in the
+// layout Paragon_GetLayerWeights returns. Nothing is changed unless the
+// matrix has exactly the layer's shape.
+//
+//export Paragon_SetLayerWeightsJSON
+func Paragon_SetLayerWeightsJSON(handle int64, layerIndex C.int, matrixJSON *C.char) *C.char {
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	defer unlock()
+
+	l := int(layerIndex)
+	r, c, err := layerWeightShape(net, l)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	var matrix [][]float64
+	if err := json.Unmarshal([]byte(C.GoString(matrixJSON)), &matrix); err != nil {
+		return handleErr(handle, fmt.Sprintf("invalid weight matrix JSON: %v", err))
+	}
+	if len(matrix) != r {
+		return handleErr(handle, fmt.Sprintf("layer %d expects a %dx%d matrix, got %d rows", l, r, c, len(matrix)))
+	}
+	for i, row := range matrix {
+		if len(row) != c {
+			return handleErr(handle, fmt.Sprintf("layer %d expects a %dx%d matrix, got %d columns in row %d", l, r, c, len(row), i))
+		}
+	}
+
+	i := 0
+	for _, row := range net.Layers[l].Neurons {
+		for _, neuron := range row {
+			for k := range neuron.Inputs {
+				neuron.Inputs[k].Weight = float32(matrix[i][k])
+			}
+			i++
+		}
+	}
+	if err := syncToGPU(net); err != nil {
+		return handleErr(handle, err.Error())
+	}
+	return asJSON(map[string]interface{}{
+		"status": "layer weights set",
+		"layer":  l,
+		"rows":   r,
+		"cols":   c,
+	})
+}
+
+// Paragon_SetActivationParameters sets parameters such as {"alpha":0.2} for
+// a layer whose activation takes them (leaky_relu, elu). They are honored by
+// the bridge's forward exports, which switch to the bridge CPU forward while
+// any are set; Paragon_Call("Forward") still uses paragon's fixed values.
+// An empty object restores the defaults.
+//
+//export Paragon_SetActivationParameters
+func Paragon_SetActivationParameters(handle int64, layerIndex C.int, paramsJSON *C.char) *C.char {
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	defer unlock()
+	e, _ := getEntry(handle)
+
+	l := int(layerIndex)
+	if l <= net.InputLayer || l >= len(net.Layers) {
+		return handleErr(handle, fmt.Sprintf("layer index %d out of range (%d..%d)", l, net.InputLayer+1, len(net.Layers)-1))
+	}
+	act := net.Layers[l].Neurons[0][0].Activation
+	defaults, ok := activationParams[act]
+	if !ok {
+		return handleErr(handle, fmt.Sprintf("activation %q of layer %d has no parameters", act, l))
+	}
+
+	var params map[string]float64
+	if err := json.Unmarshal([]byte(C.GoString(paramsJSON)), &params); err != nil {
+		return handleErr(handle, "params: "+err.Error())
+	}
+	for k := range params {
+		if _, ok := defaults[k]; !ok {
+			return handleErr(handle, fmt.Sprintf("activation %q has no parameter %q", act, k))
+		}
+	}
+
+	if len(params) == 0 {
+		delete(e.actParams, l)
+	} else {
+		if e.actParams == nil {
+			e.actParams = map[int]map[string]float64{}
+		}
+		e.actParams[l] = params
+	}
+	return asJSON(map[string]interface{}{
+		"status":     "activation parameters set",
+		"layer":      l,
+		"activation": act,
+		"params":     params,
+	})
+}
+
+// Paragon_ReplaceOutputLayer drops the output layer and appends a freshly
+// initialized, fully connected one of the given shape, keeping every other
+// layer's weights.
+//
+//export Paragon_ReplaceOutputLayer
+func Paragon_ReplaceOutputLayer(handle int64, newWidth, newHeight C.int, activation *C.char) *C.char {
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	defer unlock()
+
+	act := C.GoString(activation)
+	if !knownActivation(act) {
+		return handleErr(handle, "unknown activation: "+act)
+	}
+	if newWidth <= 0 || newHeight <= 0 {
+		return handleErr(handle, fmt.Sprintf("invalid output shape %dx%d", int(newWidth), int(newHeight)))
+	}
+	if len(net.Layers) < 2 {
+		return handleErr(handle, "network has no output layer to replace")
+	}
+
+	e, _ := getEntry(handle)
+	delete(e.actParams, net.OutputLayer)
+	delete(e.frozen, net.OutputLayer)
+	e.classNames = nil
+
+	net.Layers = net.Layers[:len(net.Layers)-1]
+	net.OutputLayer = len(net.Layers) - 1
+	net.AddLayer(len(net.Layers), int(newWidth), int(newHeight), act, true)
+
+	if err := syncToGPU(net); err != nil {
+		return handleErr(handle, err.Error())
+	}
+	return asJSON(map[string]interface{}{
+		"status": "output layer replaced",
+		"layers": architecture(net),
+	})
+}
+
+// Paragon_ResizeLayer gives hidden layer layerIndex a new shape, keeping its
+// activation (taken from its first neuron). The layer's incoming weights and
+// the next layer's weights from it are redrawn from seed as U(-1, 1), like
+// paragon's constructor, and the layer's biases start at zero; every other
+// weight and bias, including the next layer's biases, is kept. Both layers
+// must be fully connected, since paragon's local connectivity cannot be
+// rebuilt from here. A GPU-enabled handle stays on the GPU.
+//
+//export Paragon_ResizeLayer
+func Paragon_ResizeLayer(handle int64, layerIndex, newWidth, newHeight C.int, seed int64) *C.char {
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	defer unlock()
+
+	l, w, h := int(layerIndex), int(newWidth), int(newHeight)
+	if l <= net.InputLayer || l >= net.OutputLayer {
+		return handleErr(handle, fmt.Sprintf("layer %d is not a hidden layer (%d..%d)", l, net.InputLayer+1, net.OutputLayer-1))
+	}
+	if w <= 0 || h <= 0 {
+		return handleErr(handle, fmt.Sprintf("invalid layer shape %dx%d", w, h))
+	}
+	for _, k := range []int{l, l + 1} {
+		if !fullyConnected(net, k) {
+			return handleErr(handle, fmt.Sprintf("layer %d is not fully connected", k))
+		}
+	}
+	touch(handle)
+
+	rng := newRNG(seed)
+	grid := net.Layers[l]
+	previous := []int{grid.Width, grid.Height}
+	fresh := denseGrid(net, l-1, w, h, grid.Neurons[0][0].Activation, rng)
+	grid.Width, grid.Height, grid.Neurons = w, h, fresh.Neurons
+	grid.CachedOutputs, grid.CachedOutputsHistory = nil, nil
+	net.Layers[l] = grid
+	for _, row := range net.Layers[l+1].Neurons {
+		for _, neuron := range row {
+			neuron.Inputs = fullInputs(net, l, rng)
+		}
+	}
+
+	if err := syncToGPU(net); err != nil {
+		return handleErr(handle, err.Error())
+	}
+	return asJSON(map[string]interface{}{
+		"status":   "layer resized",
+		"layer":    l,
+		"previous": previous,
+		"layers":   architecture(net),
+	})
+}
+
+// Paragon_InsertLayer inserts a new fully connected layer of the given shape
+// and activation after layer afterIndex, which may be the input or a hidden
+// layer. The new layer's weights and those of the layer after it, which is
+// rewired to take its input from the new layer and must be fully connected,
+// are drawn from seed as U(-1, 1); new biases start at zero. Every other
+// weight and bias is kept, and per-layer settings such as activation
+// parameters move with their layers. Calibration ranges no longer line up
+// and are discarded. A GPU-enabled handle stays on the GPU.
+//
+//export Paragon_InsertLayer
+func Paragon_InsertLayer(handle int64, afterIndex, width, height C.int, activation *C.char, seed int64) *C.char {
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	defer unlock()
+
+	after, w, h := int(afterIndex), int(width), int(height)
+	act := C.GoString(activation)
+	if after < net.InputLayer || after >= net.OutputLayer {
+		return handleErr(handle, fmt.Sprintf("cannot insert after layer %d (%d..%d)", after, net.InputLayer, net.OutputLayer-1))
+	}
+	if w <= 0 || h <= 0 {
+		return handleErr(handle, fmt.Sprintf("invalid layer shape %dx%d", w, h))
+	}
+	if !knownActivation(act) {
+		return handleErr(handle, "unknown activation: "+act)
+	}
+	if !fullyConnected(net, after+1) {
+		return handleErr(handle, fmt.Sprintf("layer %d is not fully connected", after+1))
+	}
+	touch(handle)
+
+	k := after + 1
+	rng := newRNG(seed)
+	grid := denseGrid(net, after, w, h, act, rng)
+	net.Layers = append(net.Layers[:k], append([]paragon.Grid[float32]{grid}, net.Layers[k:]...)...)
+	net.OutputLayer = len(net.Layers) - 1
+	for l := k + 2; l < len(net.Layers); l++ {
+		for _, row := range net.Layers[l].Neurons {
+			for _, neuron := range row {
+				for c := range neuron.Inputs {
+					if neuron.Inputs[c].SourceLayer >= k {
+						neuron.Inputs[c].SourceLayer++
+					}
+				}
+			}
+		}
+	}
+	for _, row := range net.Layers[k+1].Neurons {
+		for _, neuron := range row {
+			neuron.Inputs = fullInputs(net, k, rng)
+		}
+	}
+
+	e, _ := getEntry(handle)
+	if len(e.actParams) > 0 {
+		shifted := make(map[int]map[string]float64, len(e.actParams))
+		for l, params := range e.actParams {
+			if l >= k {
+				l++
+			}
+			shifted[l] = params
+		}
+		e.actParams = shifted
+	}
+	if len(e.frozen) > 0 {
+		shifted := make(map[int]bool, len(e.frozen))
+		for l := range e.frozen {
+			if l >= k {
+				l++
+			}
+			shifted[l] = true
+		}
+		e.frozen = shifted
+	}
+	e.calib = nil
+
+	if err := syncToGPU(net); err != nil {
+		return handleErr(handle, err.Error())
+	}
+	return asJSON(map[string]interface{}{
+		"status": "layer inserted",
+		"layer":  k,
+		"layers": architecture(net),
+	})
+}
+
+// Paragon_RemoveLayer removes hidden layer index and connects the layers on
+// either side of it directly. The layer after it must be fully connected; its
+// new weights from the layer before are drawn from seed as U(-1, 1) and its
+// biases are kept, as is every other weight and bias. Per-layer settings move
+// with their layers and calibration ranges are discarded, as in
+// Paragon_InsertLayer. A GPU-enabled handle stays on the GPU.
+//
+//export Paragon_RemoveLayer
+func Paragon_RemoveLayer(handle int64, index C.int, seed int64) *C.char {
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	defer unlock()
+
+	k := int(index)
+	if k <= net.InputLayer || k >= net.OutputLayer {
+		return handleErr(handle, fmt.Sprintf("layer %d is not a hidden layer (%d..%d)", k, net.InputLayer+1, net.OutputLayer-1))
+	}
+	if !fullyConnected(net, k+1) {
+		return handleErr(handle, fmt.Sprintf("layer %d is not fully connected", k+1))
+	}
+	for l := k + 2; l < len(net.Layers); l++ {
+		for _, row := range net.Layers[l].Neurons {
+			for _, neuron := range row {
+				for _, c := range neuron.Inputs {
+					if c.SourceLayer == k {
+						return handleErr(handle, fmt.Sprintf("layer %d also feeds layer %d", k, l))
+					}
+				}
+			}
+		}
+	}
+	touch(handle)
+
+	rng := newRNG(seed)
+	for _, row := range net.Layers[k+1].Neurons {
+		for _, neuron := range row {
+			neuron.Inputs = fullInputs(net, k-1, rng)
+		}
+	}
+	net.Layers = append(net.Layers[:k], net.Layers[k+1:]...)
+	net.OutputLayer = len(net.Layers) - 1
+	for l := k + 1; l < len(net.Layers); l++ {
+		for _, row := range net.Layers[l].Neurons {
+			for _, neuron := range row {
+				for c := range neuron.Inputs {
+					if neuron.Inputs[c].SourceLayer > k {
+						neuron.Inputs[c].SourceLayer--
+					}
+				}
+			}
+		}
+	}
+
+	e, _ := getEntry(handle)
+	if len(e.actParams) > 0 {
+		shifted := make(map[int]map[string]float64, len(e.actParams))
+		for l, params := range e.actParams {
+			if l == k {
+				continue
+			}
+			if l > k {
+				l--
+			}
+			shifted[l] = params
+		}
+		e.actParams = shifted
+	}
+	if len(e.frozen) > 0 {
+		shifted := make(map[int]bool, len(e.frozen))
+		for l := range e.frozen {
+			if l == k {
+				continue
+			}
+			if l > k {
+				l--
+			}
+			shifted[l] = true
+		}
+		e.frozen = shifted
+	}
+	e.calib = nil
+
+	if err := syncToGPU(net); err != nil {
+		return handleErr(handle, err.Error())
+	}
+	return asJSON(map[string]interface{}{
+		"status": "layer removed",
+		"layer":  k,
+		"layers": architecture(net),
+	})
+}
+
+// denseGrid builds a w x h layer of act neurons, each fully connected to
+// layer src by fullInputs, with zero biases and IDs after the network's last.
+func denseGrid(net *paragon.Network[float32], src, w, h int, act string, rng *rand.Rand) paragon.Grid[float32] {
+	id := 0
+	for _, layer := range net.Layers {
+		for _, row := range layer.Neurons {
+			for _, neuron := range row {
+				if neuron.ID >= id {
+					id = neuron.ID + 1
+				}
+			}
+		}
+	}
+	grid := paragon.Grid[float32]{Width: w, Height: h, Neurons: make([][]*paragon.Neuron[float32], h)}
+	for y := range grid.Neurons {
+		grid.Neurons[y] = make([]*paragon.Neuron[float32], w)
+		for x := range grid.Neurons[y] {
+			grid.Neurons[y][x] = &paragon.Neuron[float32]{
+				ID:         id,
+				Activation: act,
+				Type:       "dense",
+				Inputs:     fullInputs(net, src, rng),
+			}
+			id++
+		}
+	}
+	return grid
+}
+
+// fullInputs connects a neuron to every neuron of layer src, row-major, with
+// weights drawn as U(-1, 1) like paragon's constructor.
+func fullInputs(net *paragon.Network[float32], src int, rng *rand.Rand) []paragon.Connection[float32] {
+	prev := net.Layers[src]
+	inputs := make([]paragon.Connection[float32], 0, prev.Width*prev.Height)
+	for y := 0; y < prev.Height; y++ {
+		for x := 0; x < prev.Width; x++ {
+			inputs = append(inputs, paragon.Connection[float32]{
+				SourceLayer: src,
+				SourceX:     x,
+				SourceY:     y,
+				Weight:      float32(rng.Float64()*2 - 1),
+			})
+		}
+	}
+	return inputs
+}
+
+// Paragon_SetOutputActivation changes only the output layer's activation, for
+// example to "linear" (or its aliases "identity" and "none") to read raw
+// logits instead of softmax probabilities, and returns the previous one so
+// it can be restored. Weights are untouched; any activation parameters set
+// on the output layer are dropped.
+//
+//export Paragon_SetOutputActivation
+func Paragon_SetOutputActivation(handle int64, activation *C.char) *C.char {
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	defer unlock()
+
+	act := C.GoString(activation)
+	if act == "identity" || act == "none" {
+		act = "linear"
+	}
+	if !knownActivation(act) {
+		return handleErr(handle, "unknown activation: "+act)
+	}
+
+	out := net.Layers[net.OutputLayer]
+	previous := out.Neurons[0][0].Activation
+	for _, row := range out.Neurons {
+		for _, neuron := range row {
+			neuron.Activation = act
+		}
+	}
+	e, _ := getEntry(handle)
+	delete(e.actParams, net.OutputLayer)
+
+	if err := syncToGPU(net); err != nil {
+		return handleErr(handle, err.Error())
+	}
+	return asJSON(map[string]interface{}{
+		"activation": act,
+		"previous":   previous,
+	})
+}
+
+// Paragon_TrainStep runs one training step on a single sample: a CPU forward
+// pass, backpropagation of the loss (cross-entropy for a softmax output
+// layer, half squared error otherwise), clipping to the handle's
+// gradient-norm cap, and an update of every weight and bias by the handle's
+// optimizer (plain SGD unless Paragon_SetOptimizer chose another). On the
+// GPU the updated weights are re-uploaded after each step.
+//
+//export Paragon_TrainStep
+func Paragon_TrainStep(handle int64, input *C.float, inputLen C.int, target *C.float, targetLen C.int, lr C.double) *C.char {
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	defer unlock()
+	e, _ := getEntry(handle)
+
+	in, err := inputFromC(net, input, inputLen)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	targets, err := targetFromC(net, target, targetLen)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	touch(handle)
+
+	loss, err := trainBatch(e, net, [][][]float64{in}, [][][]float64{targets}, float64(lr))
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+
+	return asJSON(map[string]interface{}{
+		"loss":      loss,
+		"grad_norm": e.gradNorm,
+		"clipped":   e.gradNorm < e.rawGradNorm,
+	})
+}
+
+// Paragon_NewDataset copies count samples, each inputLen input values and
+// targetLen target values laid out back to back in inputs and targets, into a
+// new dataset handle for Paragon_TrainWithValidation. Free it with
+// Paragon_Free.
+//
+//export Paragon_NewDataset
+func Paragon_NewDataset(inputs, targets *C.float, count, inputLen, targetLen C.int) *C.char {
+	switch {
+	case count < 1:
+		return errJSON(fmt.Sprintf("count must be >= 1, got %d", int(count)))
+	case inputLen < 1 || targetLen < 1:
+		return errJSON(fmt.Sprintf("invalid sample shape: input %d, target %d", int(inputLen), int(targetLen)))
+	case inputs == nil || targets == nil:
+		return errJSON("sample buffer is NULL")
+	}
+
+	n, il, tl := int(count), int(inputLen), int(targetLen)
+	in := unsafe.Slice((*float32)(unsafe.Pointer(inputs)), n*il)
+	tg := unsafe.Slice((*float32)(unsafe.Pointer(targets)), n*tl)
+	d := &dataset{inputs: make([][]float64, n), targets: make([][]float64, n)}
+	for i := 0; i < n; i++ {
+		d.inputs[i] = make([]float64, il)
+		for j := range d.inputs[i] {
+			d.inputs[i][j] = float64(in[i*il+j])
+		}
+		d.targets[i] = make([]float64, tl)
+		for j := range d.targets[i] {
+			d.targets[i][j] = float64(tg[i*tl+j])
+		}
+	}
+
+	return asJSON(map[string]interface{}{
+		"handle":  put(d),
+		"type":    "dataset",
+		"samples": n,
+	})
+}
+
+// Paragon_TrainWithValidation trains on a dataset for up to epochs passes in
+// minibatches of batchSize (the last may be smaller), taking one
+// Paragon_TrainStep-style update per batch with the handle's optimizer and
+// gradient clipping. After each epoch the mean loss over the validation
+// dataset is measured; once it has not improved for patience epochs training
+// stops and the weights of the best epoch are restored. A patience of 0 runs
+// every epoch and keeps the final weights.
+//
+//export Paragon_TrainWithValidation
+func Paragon_TrainWithValidation(handle, trainDataset, valDataset int64, epochs, batchSize C.int, lr C.double, patience C.int) *C.char {
+	train, err := getDataset(trainDataset)
+	if err != nil {
+		return handleErr(handle, "train: "+err.Error())
+	}
+	val, err := getDataset(valDataset)
+	if err != nil {
+		return handleErr(handle, "validation: "+err.Error())
+	}
+	switch {
+	case epochs < 1:
+		return handleErr(handle, fmt.Sprintf("epochs must be >= 1, got %d", int(epochs)))
+	case batchSize < 1:
+		return handleErr(handle, fmt.Sprintf("batch size must be >= 1, got %d", int(batchSize)))
+	case patience < 0:
+		return handleErr(handle, fmt.Sprintf("patience must be >= 0, got %d", int(patience)))
+	}
+
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	defer unlock()
+	e, _ := getEntry(handle)
+
+	trainIn, trainTgt, err := train.samples(net)
+	if err != nil {
+		return handleErr(handle, "train: "+err.Error())
+	}
+	valIn, valTgt, err := val.samples(net)
+	if err != nil {
+		return handleErr(handle, "validation: "+err.Error())
+	}
+	touch(handle)
+
+	bestLoss, bestEpoch, epochsRun, stale := math.Inf(1), 0, 0, 0
+	var best []float32
+	for epoch := 1; epoch <= int(epochs); epoch++ {
+		for i := 0; i < len(trainIn); i += int(batchSize) {
+			j := min(i+int(batchSize), len(trainIn))
+			if _, err := trainBatch(e, net, trainIn[i:j], trainTgt[i:j], float64(lr)); err != nil {
+				return handleErr(handle, fmt.Sprintf("epoch %d: %v", epoch, err))
+			}
+		}
+		epochsRun = epoch
+
+		var valLoss float64
+		for i, in := range valIn {
+			forwardCPU(net, e.actParams, in, nil)
+			valLoss += trainLoss(net, valTgt[i], e.loss)
+		}
+		valLoss /= float64(len(valIn))
+
+		if valLoss < bestLoss {
+			bestLoss, bestEpoch, stale = valLoss, epoch, 0
+			if patience > 0 {
+				best = best[:0]
+				for _, v := range flatWeights(net) {
+					best = append(best, float32(v))
+				}
+			}
+			continue
+		}
+		stale++
+		if patience > 0 && stale >= int(patience) {
+			break
+		}
+	}
+
+	stoppedEarly := epochsRun < int(epochs)
+	if best != nil && bestEpoch != epochsRun {
+		if err := loadFlatWeights(net, best); err != nil {
+			return handleErr(handle, err.Error())
+		}
+		if err := syncToGPU(net); err != nil {
+			return handleErr(handle, err.Error())
+		}
+	}
+	return asJSON(map[string]interface{}{
+		"best_val_loss": bestLoss,
+		"best_epoch":    bestEpoch,
+		"epochs_run":    epochsRun,
+		"stopped_early": stoppedEarly,
+	})
+}
+
+// Paragon_GetInputGradient runs a CPU forward pass and returns the gradient of
+// output targetClass (an index into the flattened output layer) with respect
+// to each input value, for saliency maps. For a softmax output layer the
+// gradient is of the class's pre-softmax logit, the usual saliency target.
+// Free the buffer with Paragon_FreeFloatBuffer; returns NULL on failure.
+//
+//export Paragon_GetInputGradient
+func Paragon_GetInputGradient(handle int64, input *C.float, length C.int, targetClass C.int) *C.float {
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		setHandleError(handle, err.Error())
+		return nil
+	}
+	defer unlock()
+	e, _ := getEntry(handle)
+
+	in, err := inputFromC(net, input, length)
+	if err != nil {
+		setHandleError(handle, err.Error())
+		return nil
+	}
+	out := net.Layers[net.OutputLayer]
+	if n := out.Width * out.Height; targetClass < 0 || int(targetClass) >= n {
+		setHandleError(handle, fmt.Sprintf("target class %d out of range for output size %d", int(targetClass), n))
+		return nil
+	}
+	touch(handle)
+
+	recordInput(e, in)
+	forwardCPU(net, e.actParams, in, nil)
+	outDelta := make([][]float64, out.Height)
+	for y := range outDelta {
+		outDelta[y] = make([]float64, out.Width)
+	}
+	outDelta[int(targetClass)/out.Width][int(targetClass)%out.Width] = 1
+	_, inGrad := backpropagate(net, e.actParams, outDelta)
+
+	flat := make([]float64, 0, int(length))
+	for _, row := range inGrad {
+		flat = append(flat, row...)
+	}
+	return floatBuf(flat)
+}
+
+// Paragon_ComputeJacobian runs a CPU forward pass and returns the Jacobian of
+// the flattened output with respect to the input at that point, row-major
+// with one row per output (rows) and one column per input value (cols). Unlike
+// Paragon_GetInputGradient, a softmax output is differentiated after the
+// softmax. It takes one backward pass per output, each touching every
+// parameter, so it costs about outputs x parameters; prefer
+// Paragon_GetInputGradient when only a few outputs matter. Free the buffer
+// with Paragon_FreeFloatBuffer; returns NULL on failure.
+//
+//export Paragon_ComputeJacobian
+func Paragon_ComputeJacobian(handle int64, input *C.float, length C.int, rows, cols *C.int) *C.float {
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		setHandleError(handle, err.Error())
+		return nil
+	}
+	defer unlock()
+	e, _ := getEntry(handle)
+
+	in, err := inputFromC(net, input, length)
+	if err != nil {
+		setHandleError(handle, err.Error())
+		return nil
+	}
+	touch(handle)
+
+	recordInput(e, in)
+	forwardCPU(net, e.actParams, in, nil)
+	out := net.Layers[net.OutputLayer]
+	pred := flatOutput(net)
+	softmax := out.Neurons[0][0].Activation == "softmax"
+	jac := make([]float64, 0, len(pred)*int(length))
+	for i := range pred {
+		outDelta := make([][]float64, out.Height)
+		for y := range outDelta {
+			outDelta[y] = make([]float64, out.Width)
+			for x := range outDelta[y] {
+				j := y*out.Width + x
+				switch {
+				case softmax && j == i:
+					outDelta[y][x] = pred[i] * (1 - pred[i])
+				case softmax:
+					outDelta[y][x] = -pred[i] * pred[j]
+				case j == i:
+					outDelta[y][x] = 1
+				}
+			}
+		}
+		_, inGrad := backpropagate(net, e.actParams, outDelta)
+		for _, row := range inGrad {
+			jac = append(jac, row...)
+		}
+	}
+
+	if rows != nil {
+		*rows = C.int(len(pred))
+	}
+	if cols != nil {
+		*cols = length
+	}
+	return floatBuf(jac)
+}
+
+// Paragon_SetDropConnect turns on DropConnect for Paragon_TrainStep and
+// Paragon_TrainWithValidation: for each training sample every connection
+// weight is dropped with probability rate and the survivors scaled by
+// 1/(1-rate), and only the kept weights receive gradient. Biases are never
+// dropped, and forward and evaluation exports always use the full weights,
+// as does training in eval mode (see Paragon_SetMode). The masks come from a
+// generator seeded with seed (see Paragon_SetRandomBackend), so a run is
+// reproducible. A rate of 0 disables it.
+//
+//export Paragon_SetDropConnect
+func Paragon_SetDropConnect(handle int64, rate C.double, seed int64) *C.char {
+	_, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	defer unlock()
+	r := float64(rate)
+	if !(r >= 0 && r < 1) {
+		return handleErr(handle, fmt.Sprintf("drop connect rate %v out of range [0,1)", r))
+	}
+
+	e, _ := getEntry(handle)
+	e.dropRate, e.dropRNG = r, nil
+	if r > 0 {
+		e.dropRNG = newRNG(seed)
+	}
+	return asJSON(map[string]interface{}{
+		"handle":  handle,
+		"rate":    r,
+		"enabled": r > 0,
+	})
+}
+
+// Paragon_SetWeightDecay sets the L2 weight decay of Paragon_TrainStep and
+// Paragon_TrainWithValidation: each step adds lambda·w to every connection
+// weight's gradient, after clipping and before the optimizer, so with SGD a
+// weight shrinks by a factor of (1 - lr·lambda) on top of its loss gradient.
+// Biases are not decayed. A lambda of 0 disables it.
+//
+//export Paragon_SetWeightDecay
+func Paragon_SetWeightDecay(handle int64, lambda C.double) *C.char {
+	_, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	defer unlock()
+	l := float64(lambda)
+	if !(l >= 0) || math.IsInf(l, 0) {
+		return handleErr(handle, fmt.Sprintf("weight decay %v must be finite and non-negative", l))
+	}
+
+	e, _ := getEntry(handle)
+	e.weightDecay = l
+	return asJSON(map[string]interface{}{
+		"handle":       handle,
+		"weight_decay": l,
+		"enabled":      l > 0,
+	})
+}
+
+// Paragon_GetWeightDecay reports the lambda set by Paragon_SetWeightDecay.
+//
+//export Paragon_GetWeightDecay
+func Paragon_GetWeightDecay(handle int64) *C.char {
+	_, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	defer unlock()
+	e, _ := getEntry(handle)
+	return asJSON(map[string]interface{}{
+		"handle":       handle,
+		"weight_decay": e.weightDecay,
+		"enabled":      e.weightDecay > 0,
+	})
+}
+
+// Paragon_SetGradientClipping caps the global L2 norm of each training step's
+// gradient at maxNorm; 0 disables clipping.
+//
+//export Paragon_SetGradientClipping
+func Paragon_SetGradientClipping(handle int64, maxNorm C.double) *C.char {
+	_, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	defer unlock()
+	if !(maxNorm >= 0) {
+		return handleErr(handle, fmt.Sprintf("max norm must be >= 0, got %v", float64(maxNorm)))
+	}
+	e, _ := getEntry(handle)
+	e.gradClip = float64(maxNorm)
+
+	return asJSON(map[string]interface{}{
+		"handle":   handle,
+		"max_norm": e.gradClip,
+	})
+}
+
+// Paragon_GetGradientClipping reports the max norm set by
+// Paragon_SetGradientClipping and whether clipping is enabled.
+//
+//export Paragon_GetGradientClipping
+func Paragon_GetGradientClipping(handle int64) *C.char {
+	_, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	defer unlock()
+	e, _ := getEntry(handle)
+
+	return asJSON(map[string]interface{}{
+		"max_norm": e.gradClip,
+		"enabled":  e.gradClip > 0,
+	})
+}
+
+// Paragon_SetLossFunction selects the loss Paragon_TrainStep and
+// Paragon_TrainWithValidation minimize and report: "mse", "cross_entropy",
+// "binary_cross_entropy" or "huber" (delta 1), each as Paragon_ComputeLoss
+// computes it. "default" restores the built-in choice of cross-entropy for a
+// softmax output layer and half the squared error otherwise.
+//
+//export Paragon_SetLossFunction
+func Paragon_SetLossFunction(handle int64, name *C.char) *C.char {
+	_, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	defer unlock()
+
+	loss := C.GoString(name)
+	if _, ok := lossFunctions[loss]; !ok && loss != "default" {
+		return handleErr(handle, fmt.Sprintf("unknown loss %q (want %s or default)", loss, strings.Join(lossNames(), ", ")))
+	}
+	e, _ := getEntry(handle)
+	e.loss = loss
+	if loss == "default" {
+		e.loss = ""
+	}
+	return asJSON(map[string]interface{}{"loss": loss})
+}
+
+// Paragon_GetLossFunction reports the training loss set by
+// Paragon_SetLossFunction, or "default".
+//
+//export Paragon_GetLossFunction
+func Paragon_GetLossFunction(handle int64) *C.char {
+	_, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	defer unlock()
+
+	e, _ := getEntry(handle)
+	loss := e.loss
+	if loss == "" {
+		loss = "default"
+	}
+	return asJSON(map[string]interface{}{
+		"loss":      loss,
+		"available": lossNames(),
+	})
+}
+
+func lossNames() []string {
+	names := make([]string, 0, len(lossFunctions))
+	for name := range lossFunctions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Paragon_SetOptimizer selects the update rule used by Paragon_TrainStep
+// ("sgd", "momentum", "adam" or "rmsprop") with optional hyperparameters as
+// {"beta1":0.9,...}; omitted ones keep their defaults. Any existing optimizer
+// state is discarded.
+//
+//export Paragon_SetOptimizer
+func Paragon_SetOptimizer(handle int64, name *C.char, hyperparamsJSON *C.char) *C.char {
+	_, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	defer unlock()
+
+	hyper := map[string]float64{}
+	if raw := C.GoString(hyperparamsJSON); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &hyper); err != nil {
+			return handleErr(handle, fmt.Sprintf("Invalid JSON input: %v", err))
+		}
+	}
+	o, err := newOptimizer(C.GoString(name), hyper)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	e, _ := getEntry(handle)
+	e.opt = o
+
+	return asJSON(map[string]interface{}{
+		"handle":          handle,
+		"optimizer":       o.Name,
+		"hyperparameters": o.Hyper,
+	})
+}
+
+// Paragon_GetOptimizerState serializes the handle's optimizer, including its
+// step count and per-parameter state vectors (in the flatWeights layout), as
+// {"name","hyperparameters","step","slots"}. Paragon_SetOptimizerState
+// restores it, so training resumed from this state and the matching weights
+// takes the same steps it would have without the interruption.
+//
+//export Paragon_GetOptimizerState
+func Paragon_GetOptimizerState(handle int64) *C.char {
+	_, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	defer unlock()
+	e, _ := getEntry(handle)
+
+	o := e.opt
+	if o == nil {
+		o, _ = newOptimizer("sgd", nil)
+	}
+	return asJSON(o)
+}
+
+// Paragon_SetOptimizerState replaces the handle's optimizer with one restored
+// from Paragon_GetOptimizerState's JSON. Each slot must hold one value per
+// parameter of this network; a state with step 0 may leave slots empty.
+//
+//export Paragon_SetOptimizerState
+func Paragon_SetOptimizerState(handle int64, stateJSON *C.char) *C.char {
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	defer unlock()
+
+	var state optimizer
+	if err := json.Unmarshal([]byte(C.GoString(stateJSON)), &state); err != nil {
+		return handleErr(handle, fmt.Sprintf("Invalid JSON input: %v", err))
+	}
+	o, err := newOptimizer(state.Name, state.Hyper)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	if state.Step < 0 {
+		return handleErr(handle, fmt.Sprintf("step must be >= 0, got %d", state.Step))
+	}
+	want := optimizerSlots[o.Name]
+	for name := range state.Slots {
+		if !slices.Contains(want, name) {
+			return handleErr(handle, fmt.Sprintf("optimizer %q has no slot %q (keeps %v)", o.Name, name, want))
+		}
+	}
+	n := paramCount(net)
+	for _, name := range want {
+		slot, ok := state.Slots[name]
+		if len(slot) == 0 && state.Step == 0 {
+			// A fresh optimizer has no state yet; update allocates it.
+			continue
+		}
+		if !ok {
+			return handleErr(handle, fmt.Sprintf("optimizer %q state is missing slot %q", o.Name, name))
+		}
+		if len(slot) != n {
+			return handleErr(handle, fmt.Sprintf("slot %q has %d values, network has %d parameters", name, len(slot), n))
+		}
+		o.Slots[name] = slot
+	}
+	o.Step = state.Step
+	e, _ := getEntry(handle)
+	e.opt = o
+
+	return asJSON(map[string]interface{}{
+		"handle":    handle,
+		"optimizer": o.Name,
+		"step":      o.Step,
+	})
+}
+
+// Paragon_GetGradientNorm reports the gradient norm of the last
+// Paragon_TrainStep, as applied (after clipping) and as computed.
+//
+//export Paragon_GetGradientNorm
+func Paragon_GetGradientNorm(handle int64) *C.char {
+	_, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	defer unlock()
+	e, _ := getEntry(handle)
+	if !e.trained {
+		return handleErr(handle, "no training step has run on this handle")
+	}
+
+	return asJSON(map[string]interface{}{
+		"norm":           e.gradNorm,
+		"unclipped_norm": e.rawGradNorm,
+		"clipped":        e.gradNorm < e.rawGradNorm,
+	})
 }
 
-// C ABI Exports
+// Paragon_Quantize makes a new Network[int8] handle from a float32 network
+// with one scale (and, for "asymmetric", a zero point) per layer covering its
+// weights and biases, and returns the new handle with the scales. Each
+// parameter is recovered to within half a step (scale/2) by
+// Paragon_Dequantize; layers with a few large outliers lose the most
+// precision, and "asymmetric" helps layers whose values are not centred on
+// zero. Paragon's integer forward pass uses its own fixed-point convention
+// (127 as 1.0) and does not see these scales, so compare accuracy on the
+// dequantized network.
+//
+//export Paragon_Quantize
+func Paragon_Quantize(handle int64, scheme *C.char) *C.char {
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	defer unlock()
+	sch := C.GoString(scheme)
+	if sch != "symmetric" && sch != "asymmetric" {
+		return handleErr(handle, fmt.Sprintf("unknown quantization scheme %q (want symmetric or asymmetric)", sch))
+	}
 
-//export Paragon_NewNetworkFloat32
-func Paragon_NewNetworkFloat32(
-	layersJSON, activationsJSON, fullyJSON *C.char,
-	useGPU C.bool,
-	debug C.bool,
-) *C.char {
-	var layers []struct{ Width, Height int }
-	var acts []string
-	var fully []bool
+	qnet, err := paragon.ConvertNetwork[float32, int8](net)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	q := &quantization{Scheme: sch}
+	var maxErr float64
+	for l := net.InputLayer + 1; l < len(net.Layers); l++ {
+		lo, hi := math.Inf(1), math.Inf(-1)
+		for _, row := range net.Layers[l].Neurons {
+			for _, neuron := range row {
+				lo, hi = math.Min(lo, float64(neuron.Bias)), math.Max(hi, float64(neuron.Bias))
+				for _, c := range neuron.Inputs {
+					lo, hi = math.Min(lo, float64(c.Weight)), math.Max(hi, float64(c.Weight))
+				}
+			}
+		}
+		scale, zero := quantParams(sch, lo, hi)
+		q.Layers = append(q.Layers, layerQuant{Index: l, Scale: scale, ZeroPoint: zero})
 
-	if err := json.Unmarshal([]byte(C.GoString(layersJSON)), &layers); err != nil {
-		return errJSON("layers: " + err.Error())
+		conv := func(v float32) int8 {
+			qv := quantize(float64(v), scale, zero)
+			maxErr = math.Max(maxErr, math.Abs(float64(int(qv)-zero)*scale-float64(v)))
+			return qv
+		}
+		for y, row := range net.Layers[l].Neurons {
+			for x, neuron := range row {
+				dst := qnet.Layers[l].Neurons[y][x]
+				dst.Bias = conv(neuron.Bias)
+				for k, c := range neuron.Inputs {
+					dst.Inputs[k].Weight = conv(c.Weight)
+				}
+			}
+		}
 	}
-	if err := json.Unmarshal([]byte(C.GoString(activationsJSON)), &acts); err != nil {
-		return errJSON("activations: " + err.Error())
+
+	id, ok := putIn(namespaceOf(handle), qnet)
+	if !ok {
+		return handleErr(handle, fmt.Sprintf("namespace %d was freed", namespaceOf(handle)))
 	}
-	if err := json.Unmarshal([]byte(C.GoString(fullyJSON)), &fully); err != nil {
-		return errJSON("fullyConnected: " + err.Error())
+	e, _ := getEntry(id)
+	e.quant = q
+	return asJSON(map[string]interface{}{
+		"handle":        id,
+		"type":          "Network[int8]",
+		"scheme":        q.Scheme,
+		"layers":        q.Layers,
+		"max_abs_error": maxErr,
+	})
+}
+
+// Paragon_Dequantize rebuilds a float network ("float32" or "float64") from a
+// Paragon_Quantize handle and returns the new handle; each parameter is
+// within half a quantization step of the original.
+//
+//export Paragon_Dequantize
+func Paragon_Dequantize(handle int64, targetType *C.char) *C.char {
+	e, ok := getEntry(handle)
+	if !ok {
+		return handleErr(handle, fmt.Sprintf("invalid handle %d", handle))
+	}
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	src, ok := e.obj.(*paragon.Network[int8])
+	if !ok || e.quant == nil {
+		return handleErr(handle, "not a quantized Network[int8] (use Paragon_Quantize)")
 	}
+	touch(handle)
 
-	net, err := paragon.NewNetwork[float32](layers, acts, fully)
+	var (
+		obj interface{}
+		err error
+	)
+	switch t := C.GoString(targetType); t {
+	case "float32":
+		obj, err = dequantize[float32](src, e.quant)
+	case "float64":
+		obj, err = dequantize[float64](src, e.quant)
+	default:
+		return handleErr(handle, fmt.Sprintf("unsupported target type %q (want float32 or float64)", t))
+	}
 	if err != nil {
-		return errJSON("new network: " + err.Error())
+		return handleErr(handle, err.Error())
 	}
 
-	// Defaults first
-	net.WebGPUNative = false
-	net.Debug = bool(debug)
+	id, ok := putIn(namespaceOf(handle), obj)
+	if !ok {
+		return handleErr(handle, fmt.Sprintf("namespace %d was freed", namespaceOf(handle)))
+	}
+	return asJSON(map[string]interface{}{
+		"handle": id,
+		"type":   reflect.TypeOf(obj).Elem().Name(),
+	})
+}
 
-	var gpuInitOK bool
-	var gpuInitMs int64
+// Paragon_GetOutputInt8 returns the output of the last forward pass on a
+// Network[int8] handle, such as a reflected Paragon_Call("Forward") on a
+// Paragon_Quantize result, as raw int8 values in row-major order, storing the
+// count in outLen. The values follow paragon's integer forward convention
+// (127 as 1.0), not the per-layer scales recorded by Paragon_Quantize, which
+// describe only the stored weights and biases. Free the buffer with
+// Paragon_FreeIntBuffer. Returns NULL for any other handle type.
+//
+//export Paragon_GetOutputInt8
+func Paragon_GetOutputInt8(handle int64, outLen *C.int) *C.int8_t {
+	e, ok := getEntry(handle)
+	if !ok {
+		setHandleError(handle, fmt.Sprintf("invalid handle %d", handle))
+		return nil
+	}
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+	net, ok := e.obj.(*paragon.Network[int8])
+	if !ok {
+		setHandleError(handle, fmt.Sprintf("handle %d is %T, not a Network[int8]", handle, e.obj))
+		return nil
+	}
+	touch(handle)
 
-	if bool(useGPU) {
-		startGPU := time.Now()
-		net.WebGPUNative = true
-		if err := net.InitializeOptimizedGPU(); err != nil {
-			// Fall back to CPU if init fails
-			net.WebGPUNative = false
-			gpuInitOK = false
-			gpuInitMs = time.Since(startGPU).Milliseconds()
-		} else {
-			gpuInitOK = true
-			gpuInitMs = time.Since(startGPU).Milliseconds()
-			// IMPORTANT: do NOT CleanupOptimizedGPU here—caller owns the handle.
+	out := net.Layers[net.OutputLayer]
+	n := out.Width * out.Height
+	p := (*C.int8_t)(C.malloc(C.size_t(n)))
+	dst := unsafe.Slice((*int8)(unsafe.Pointer(p)), n)
+	i := 0
+	for _, row := range out.Neurons {
+		for _, neuron := range row {
+			dst[i] = neuron.Value
+			i++
 		}
 	}
+	if outLen != nil {
+		*outLen = C.int(n)
+	}
+	return p
+}
+
+// Paragon_GetGPUUtilization reports the share of wall time this handle spent
+// in GPU forward passes since the previous call (or since it was created) as
+// utilization_pct, 0 for a CPU network. WebGPU exposes no adapter counters,
+// so this is measured by the bridge around each forward export and reflected
+// Forward* call: it includes upload and readback, misses other processes and
+// other handles sharing the adapter, and is only as fine-grained as the calls
+// themselves. For the same reason the adapter's total memory is unknown:
+// memory_pct is null, and gpu_bytes gives this handle's buffers as in
+// Paragon_GetMemoryReport.
+//
+//export Paragon_GetGPUUtilization
+func Paragon_GetGPUUtilization(handle int64) *C.char {
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	defer unlock()
+	e, _ := getEntry(handle)
 
-	id := put(net)
+	now, busy := time.Now(), e.gpuBusy.Load()
+	since := e.utilAt
+	if since.IsZero() {
+		since = e.created
+	}
+	pct := 0.0
+	if window := now.Sub(since); window > 0 {
+		pct = math.Min(100*float64(busy-e.utilBusy)/float64(window), 100)
+	}
+	e.utilAt, e.utilBusy = now, busy
 	return asJSON(map[string]interface{}{
-		"handle":      id,
-		"type":        "Network[float32]",
-		"layers":      len(layers),
-		"gpu":         net.WebGPUNative,
-		"gpu_init_ok": gpuInitOK,
-		"gpu_init_ms": gpuInitMs,
-		"debug":       net.Debug,
+		"handle":          handle,
+		"gpu":             net.WebGPUNative,
+		"utilization_pct": pct,
+		"window_ms":       millis(now.Sub(since)),
+		"memory_pct":      nil,
+		"gpu_bytes":       gpuBytes(net),
 	})
 }
 
-//export Paragon_Call
-func Paragon_Call(handle int64, method *C.char, argsJSON *C.char) *C.char {
-	obj, ok := get(handle)
-	if !ok {
-		return errJSON(fmt.Sprintf("invalid handle %d", handle))
+// Paragon_GetMemoryReport sums memory across every live handle. CPU bytes
+// count float32 parameters; GPU bytes are computed from the buffers the GPU
+// path allocates, since WebGPU exposes no usage query.
+//
+//export Paragon_GetMemoryReport
+func Paragon_GetMemoryReport() *C.char {
+	mu.Lock()
+	ids := make([]int64, 0, len(objects))
+	entries := make(map[int64]*entry, len(objects))
+	for id, e := range objects {
+		ids = append(ids, id)
+		entries[id] = e
 	}
+	mu.Unlock()
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
 
-	methodName := C.GoString(method)
-	m := reflect.ValueOf(obj).MethodByName(methodName)
-	if !m.IsValid() {
-		return errJSON("Method not found: " + methodName)
+	var totalCPU, totalGPU int64
+	perHandle := make([]map[string]interface{}, 0, len(ids))
+	for _, id := range ids {
+		e := entries[id]
+		item := map[string]interface{}{
+			"handle": id,
+			"type":   reflect.TypeOf(e.obj).String(),
+		}
+		var cpu, gpu int64
+		if net, ok := e.obj.(*paragon.Network[float32]); ok {
+			e.lock.RLock()
+			cpu = int64(paramCount(net)) * 4
+			gpu = gpuBytes(net)
+			item["gpu"] = net.WebGPUNative
+			e.lock.RUnlock()
+		}
+		item["cpu_bytes"] = cpu
+		item["gpu_bytes"] = gpu
+		totalCPU += cpu
+		totalGPU += gpu
+		perHandle = append(perHandle, item)
 	}
 
-	return callMethodWithJSON(m, C.GoString(argsJSON))
+	return asJSON(map[string]interface{}{
+		"total_cpu_bytes": totalCPU,
+		"total_gpu_bytes": totalGPU,
+		"handle_count":    len(ids),
+		"per_handle":      perHandle,
+	})
 }
 
-//export Paragon_ListMethods
-func Paragon_ListMethods(handle int64) *C.char {
-	obj, ok := get(handle)
+// Paragon_Free drops one owner of a handle (see Paragon_Retain). When none
+// remain it waits for in-flight calls and releases the handle and its GPU
+// resources. An unfilled reservation is released outright.
+//
+//export Paragon_Free
+func Paragon_Free(handle int64) {
+	if release(handle) {
+		freeHandle(handle)
+	}
+}
+
+// release drops one owner of a handle and reports whether none remain, or
+// the handle is not live, so it should be freed.
+func release(handle int64) bool {
+	mu.Lock()
+	defer mu.Unlock()
+	e, ok := objects[handle]
 	if !ok {
-		return errJSON("invalid handle")
+		return true
 	}
+	e.refs--
+	return e.refs <= 0
+}
 
-	val := reflect.ValueOf(obj)
-	typ := val.Type()
-	methods := make([]map[string]interface{}, 0)
+// freeHandle releases a handle whatever its owner count.
+func freeHandle(handle int64) {
+	// Clean up GPU resources if it's a network
+	if e, ok := getEntry(handle); ok {
+		// Wait for in-flight reflected calls on this handle
+		e.lock.Lock()
+		defer e.lock.Unlock()
+		if net, ok := e.obj.(*paragon.Network[float32]); ok {
+			net.CleanupOptimizedGPU()
+		}
+	}
+	del(handle)
+}
 
-	for i := 0; i < typ.NumMethod(); i++ {
-		method := typ.Method(i)
-		if method.Name[0] >= 'A' && method.Name[0] <= 'Z' {
-			methodType := method.Type
-			params := make([]string, methodType.NumIn()-1) // -1 for receiver
-			for j := 1; j < methodType.NumIn(); j++ {
-				params[j-1] = methodType.In(j).String()
-			}
-			returns := make([]string, methodType.NumOut())
-			for j := 0; j < methodType.NumOut(); j++ {
-				returns[j] = methodType.Out(j).String()
-			}
+// Paragon_BatchFree drops one owner of each handle in a JSON array, as
+// Paragon_Free does, removing those left without one from the registry under
+// one lock before cleaning each up. It returns
+// {"freed":N,"errors":[{"handle":ID,"error":"..."}]}, where freed counts the
+// handles actually released and errors lists IDs that were not live (or
+// were repeated after their last owner was dropped).
+//
+//export Paragon_BatchFree
+func Paragon_BatchFree(handlesJSON *C.char) *C.char {
+	var ids []int64
+	if err := json.Unmarshal([]byte(C.GoString(handlesJSON)), &ids); err != nil {
+		return errJSON(fmt.Sprintf("Invalid JSON input: %v", err))
+	}
 
-			methods = append(methods, map[string]interface{}{
-				"name":       method.Name,
-				"parameters": params,
-				"returns":    returns,
-			})
+	errs := make([]map[string]interface{}, 0)
+	removed := make([]*entry, 0, len(ids))
+	mu.Lock()
+	for _, id := range ids {
+		e, ok := objects[id]
+		if !ok {
+			errs = append(errs, map[string]interface{}{"handle": id, "error": fmt.Sprintf("invalid handle %d", id)})
+			continue
+		}
+		if e.refs--; e.refs > 0 {
+			continue
 		}
+		delete(objects, id)
+		removed = append(removed, e)
 	}
+	mu.Unlock()
 
+	for _, e := range removed {
+		// Wait for in-flight calls before releasing resources
+		e.lock.Lock()
+		if net, ok := e.obj.(*paragon.Network[float32]); ok {
+			net.CleanupOptimizedGPU()
+		}
+		if e.outBuf != nil {
+			C.free(unsafe.Pointer(e.outBuf))
+			e.outBuf = nil
+		}
+		e.lock.Unlock()
+	}
 	return asJSON(map[string]interface{}{
-		"methods": methods,
-		"count":   len(methods),
+		"freed":  len(removed),
+		"errors": errs,
 	})
 }
 
-//export Paragon_GetInfo
-func Paragon_GetInfo(handle int64) *C.char {
-	obj, ok := get(handle)
-	if !ok {
-		return errJSON("invalid handle")
+// Paragon_ReserveHandles sets aside n consecutive handle IDs in the default
+// namespace and returns the first, so a host can plan its handle layout up
+// front. Each reserved ID is filled by Paragon_NewNetworkFloat32Reserved and
+// from then on is an ordinary handle. Until filled it is not a live handle
+// (other exports reject it) and is never handed out again; Paragon_Free
+// releases an unfilled reservation.
+//
+//export Paragon_ReserveHandles
+func Paragon_ReserveHandles(n int64) *C.char {
+	if n < 1 {
+		return errJSON(fmt.Sprintf("n must be >= 1, got %d", n))
+	}
+	mu.Lock()
+	if nextID+n > 1<<namespaceShift {
+		mu.Unlock()
+		return errJSON(fmt.Sprintf("cannot reserve %d handles: default namespace exhausted", n))
 	}
+	first := nextID
+	for id := first; id < first+n; id++ {
+		reserved[id] = true
+	}
+	nextID += n
+	mu.Unlock()
+	return asJSON(map[string]interface{}{
+		"first": first,
+		"count": n,
+	})
+}
 
-	val := reflect.ValueOf(obj)
-	typ := val.Type()
+// Paragon_NewNamespace opens an isolated handle space, so independent users
+// of one loaded library cannot mistake each other's handles (see
+// Paragon_NewNetworkFloat32InNamespace). Handles derived from a namespaced
+// handle, such as Paragon_Quantize results, stay in its namespace.
+//
+//export Paragon_NewNamespace
+func Paragon_NewNamespace() *C.char {
+	mu.Lock()
+	defer mu.Unlock()
+	ns := nextNS
+	nextNS++
+	namespaces[ns] = 1
+	return asJSON(map[string]interface{}{"namespace": ns})
+}
 
-	info := map[string]interface{}{
-		"type":    typ.String(),
-		"kind":    typ.Kind().String(),
-		"methods": typ.NumMethod(),
-		"handle":  handle,
+// Paragon_FreeNamespace frees every handle in the namespace, however many
+// owners it has (see Paragon_Retain), then the namespace itself.
+//
+//export Paragon_FreeNamespace
+func Paragon_FreeNamespace(namespace int64) *C.char {
+	mu.Lock()
+	if _, ok := namespaces[namespace]; !ok {
+		mu.Unlock()
+		return errJSON(fmt.Sprintf("invalid namespace %d", namespace))
+	}
+	delete(namespaces, namespace)
+	var ids []int64
+	for id := range objects {
+		if namespaceOf(id) == namespace {
+			ids = append(ids, id)
+		}
 	}
+	mu.Unlock()
 
-	// Add network-specific info if it's a network
-	if net, ok := obj.(*paragon.Network[float32]); ok {
-		info["webgpu_native"] = net.WebGPUNative
-		info["debug"] = net.Debug
-		// Add more network-specific info as needed
+	for _, id := range ids {
+		freeHandle(id)
 	}
+	return asJSON(map[string]interface{}{
+		"namespace": namespace,
+		"freed":     len(ids),
+	})
+}
 
-	return asJSON(info)
+// Paragon_SetMode switches a network handle between "train" (the default)
+// and "eval". Eval mode switches dropout off: Paragon_ForwardWithDropout runs
+// a plain forward pass and training ignores Paragon_SetDropConnect. Paragon's
+// layers are all dense, so there are no normalization statistics to freeze
+// and ordinary forward passes are identical in both modes. The mode is
+// reported by Paragon_GetInfo.
+//
+//export Paragon_SetMode
+func Paragon_SetMode(handle int64, mode *C.char) *C.char {
+	_, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
+	}
+	defer unlock()
+
+	m := C.GoString(mode)
+	if m != "train" && m != "eval" {
+		return handleErr(handle, fmt.Sprintf("unknown mode %q (want train or eval)", m))
+	}
+	e, _ := getEntry(handle)
+	e.evalMode = m == "eval"
+	return asJSON(map[string]interface{}{
+		"handle": handle,
+		"mode":   m,
+	})
 }
 
-//export Paragon_EnableGPU
-func Paragon_EnableGPU(handle int64) *C.char {
-	obj, ok := get(handle)
-	if !ok {
-		return errJSON("invalid handle")
+// Paragon_GetRunningStatistics is meant to return a batch-normalization
+// layer's {"running_mean":[...],"running_var":[...]}. Paragon keeps no
+// normalization statistics (its layers are all dense, see
+// Paragon_GetLayerType), so after validating the index it reports that the
+// layer has none.
+//
+//export Paragon_GetRunningStatistics
+func Paragon_GetRunningStatistics(handle int64, layerIndex C.int) *C.char {
+	net, unlock, err := lockNetwork(handle)
+	if err != nil {
+		return handleErr(handle, err.Error())
 	}
+	defer unlock()
 
-	net, ok := obj.(*paragon.Network[float32])
-	if !ok {
-		return errJSON("not a Network[float32]")
+	l := int(layerIndex)
+	if l < 0 || l >= len(net.Layers) {
+		return handleErr(handle, fmt.Sprintf("layer index %d out of range (0..%d)", l, len(net.Layers)-1))
 	}
+	return handleErr(handle, fmt.Sprintf("layer %d (%s) has no batch normalization statistics", l, net.Layers[l].Neurons[0][0].Type))
+}
 
-	net.WebGPUNative = true
-	if err := net.InitializeOptimizedGPU(); err != nil {
-		net.WebGPUNative = false
-		return errJSON("failed to initialize GPU: " + err.Error())
+func modeName(e *entry) string {
+	if e.evalMode {
+		return "eval"
 	}
+	return "train"
+}
 
+// Paragon_GetForwardCount reports how many forward passes the handle has
+// served: forward exports plus Paragon_Call methods named Forward*, each
+// counted once per call whether or not it succeeded, since the handle was
+// created or Paragon_ResetForwardCount last zeroed it.
+//
+//export Paragon_GetForwardCount
+func Paragon_GetForwardCount(handle int64) *C.char {
+	e, ok := getEntry(handle)
+	if !ok {
+		return handleErr(handle, fmt.Sprintf("invalid handle %d", handle))
+	}
 	return asJSON(map[string]interface{}{
-		"status": "GPU enabled",
 		"handle": handle,
+		"count":  e.forwards.Load(),
 	})
 }
 
-//export Paragon_DisableGPU
-func Paragon_DisableGPU(handle int64) *C.char {
-	obj, ok := get(handle)
+// Paragon_ResetForwardCount zeroes the handle's forward count and returns
+// the count it had, so a host sampling QPS can read and reset in one call.
+// The bridge has no whole-network reset export; this is the counter's only
+// reset.
+//
+//export Paragon_ResetForwardCount
+func Paragon_ResetForwardCount(handle int64) *C.char {
+	e, ok := getEntry(handle)
 	if !ok {
-		return errJSON("invalid handle")
+		return handleErr(handle, fmt.Sprintf("invalid handle %d", handle))
 	}
+	return asJSON(map[string]interface{}{
+		"handle": handle,
+		"count":  e.forwards.Swap(0),
+	})
+}
 
-	net, ok := obj.(*paragon.Network[float32])
+// Paragon_GetHandleAge reports when a handle was registered and how long ago.
+//
+//export Paragon_GetHandleAge
+func Paragon_GetHandleAge(handle int64) *C.char {
+	e, ok := getEntry(handle)
 	if !ok {
-		return errJSON("not a Network[float32]")
+		return handleErr(handle, fmt.Sprintf("invalid handle %d", handle))
 	}
-
-	net.CleanupOptimizedGPU()
-	net.WebGPUNative = false
-
 	return asJSON(map[string]interface{}{
-		"status": "GPU disabled",
-		"handle": handle,
+		"handle":          handle,
+		"created_unix_ms": e.created.UnixMilli(),
+		"age_ms":          time.Since(e.created).Milliseconds(),
 	})
 }
 
-//export Paragon_PerturbWeights
-func Paragon_PerturbWeights(handle int64, magnitude float64, seed int64) *C.char {
-	obj, ok := get(handle)
-	if !ok {
-		return errJSON("invalid handle")
+// Paragon_Retain adds an owner to a handle, so it stays live until each
+// owner, including the one that created it, has called Paragon_Free.
+//
+//export Paragon_Retain
+func Paragon_Retain(handle int64) *C.char {
+	mu.Lock()
+	e, ok := objects[handle]
+	if !ok || e.refs < 1 {
+		mu.Unlock()
+		return handleErr(handle, fmt.Sprintf("invalid handle %d", handle))
 	}
+	e.refs++
+	n := e.refs
+	mu.Unlock()
+	return asJSON(map[string]interface{}{"handle": handle, "refcount": n})
+}
 
-	net, ok := obj.(*paragon.Network[float32])
+// Paragon_GetRefCount reports how many owners a handle has: 1 from its
+// creation plus one per Paragon_Retain not yet matched by a Paragon_Free.
+//
+//export Paragon_GetRefCount
+func Paragon_GetRefCount(handle int64) *C.char {
+	mu.Lock()
+	e, ok := objects[handle]
+	var n int
+	if ok {
+		n = e.refs
+	}
+	mu.Unlock()
 	if !ok {
-		return errJSON("not a Network[float32]")
+		return handleErr(handle, fmt.Sprintf("invalid handle %d", handle))
 	}
+	return asJSON(map[string]interface{}{"handle": handle, "refcount": n})
+}
 
-	net.PerturbWeights(magnitude, int(seed))
-	return asJSON(map[string]string{"status": "weights perturbed"})
+// Paragon_ListHandles lists every live handle in ascending order with its
+// object type, creation time and last use (both Unix milliseconds), owner
+// count (see Paragon_GetRefCount), forward count (see
+// Paragon_GetForwardCount) and last error (see Paragon_GetHandleError), with
+// last_error_time only when there is one.
+//
+//export Paragon_ListHandles
+func Paragon_ListHandles() *C.char {
+	mu.Lock()
+	errMu.Lock()
+	handles := make([]map[string]interface{}, 0, len(objects))
+	for id, e := range objects {
+		h := map[string]interface{}{
+			"handle":            id,
+			"type":              reflect.TypeOf(e.obj).String(),
+			"created_unix_ms":   e.created.UnixMilli(),
+			"last_used_unix_ms": e.lastUsed.UnixMilli(),
+			"refcount":          e.refs,
+			"forwards":          e.forwards.Load(),
+			"last_error":        e.lastErr,
+		}
+		if e.lastErr != "" {
+			h["last_error_time"] = e.lastErrTime
+		}
+		handles = append(handles, h)
+	}
+	errMu.Unlock()
+	mu.Unlock()
+
+	sort.Slice(handles, func(i, j int) bool { return handles[i]["handle"].(int64) < handles[j]["handle"].(int64) })
+	return asJSON(map[string]interface{}{
+		"handles": handles,
+		"count":   len(handles),
+	})
 }
 
-//export Paragon_Free
-func Paragon_Free(handle int64) {
-	// Clean up GPU resources if it's a network
-	if obj, ok := get(handle); ok {
-		if net, ok := obj.(*paragon.Network[float32]); ok {
-			net.CleanupOptimizedGPU()
+//export Paragon_Touch
+func Paragon_Touch(handle int64) *C.char {
+	if _, ok := get(handle); !ok {
+		return handleErr(handle, "invalid handle")
+	}
+	touch(handle)
+	return asJSON(map[string]interface{}{
+		"status": "touched",
+		"handle": handle,
+	})
+}
+
+// Paragon_EvictIdle frees every handle not used by Paragon_Call or a forward
+// export for longer than maxIdleMs, however many owners it has (see
+// Paragon_Retain).
+//
+//export Paragon_EvictIdle
+func Paragon_EvictIdle(maxIdleMs int64) *C.char {
+	cutoff := time.Now().Add(-time.Duration(maxIdleMs) * time.Millisecond)
+
+	mu.Lock()
+	idle := make([]int64, 0)
+	for id, e := range objects {
+		if e.lastUsed.Before(cutoff) {
+			idle = append(idle, id)
 		}
 	}
-	del(handle)
+	mu.Unlock()
+
+	sort.Slice(idle, func(i, j int) bool { return idle[i] < idle[j] })
+	for _, id := range idle {
+		freeHandle(id)
+	}
+
+	return asJSON(map[string]interface{}{
+		"freed": idle,
+		"count": len(idle),
+	})
 }
 
 //export Paragon_FreeCString
@@ -510,9 +7801,34 @@ func Paragon_FreeCString(p *C.char) {
 	C.free(unsafe.Pointer(p))
 }
 
+//export Paragon_FreeFloatBuffer
+func Paragon_FreeFloatBuffer(p *C.float) {
+	C.free(unsafe.Pointer(p))
+}
+
+//export Paragon_FreeIntBuffer
+func Paragon_FreeIntBuffer(p *C.int8_t) {
+	C.free(unsafe.Pointer(p))
+}
+
 //export Paragon_GetVersion
 func Paragon_GetVersion() *C.char {
-	return cstr("Paragon C ABI v1.0 (float32)")
+	return cstr(fmt.Sprintf("Paragon C ABI v%d.%d (float32)", abiMajor, abiMinor))
+}
+
+// Paragon_CheckABI reports whether a binding generated against
+// expectedMajor.expectedMinor can safely use this library: the major version
+// must match exactly and the library's minor version must be at least the
+// expected one.
+//
+//export Paragon_CheckABI
+func Paragon_CheckABI(expectedMajor, expectedMinor C.int) *C.char {
+	compatible := int(expectedMajor) == abiMajor && abiMinor >= int(expectedMinor)
+	return asJSON(map[string]interface{}{
+		"compatible": compatible,
+		"actual":     fmt.Sprintf("%d.%d", abiMajor, abiMinor),
+		"expected":   fmt.Sprintf("%d.%d", int(expectedMajor), int(expectedMinor)),
+	})
 }
 
 func main() {
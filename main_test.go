@@ -0,0 +1,524 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+// The tests drive the exports from Go: cstr and floatBuf build their C
+// arguments, and goString reads their C results, since a test file cannot
+// import "C" itself. Run with -race; several exports lock per handle.
+
+// goString copies the NUL-terminated C string at p.
+func goString[T any](p *T) string {
+	var b []byte
+	for q := unsafe.Pointer(p); *(*byte)(q) != 0; q = unsafe.Add(q, 1) {
+		b = append(b, *(*byte)(q))
+	}
+	return string(b)
+}
+
+// decode unmarshals an export's JSON result into v, failing the test on an
+// {"error"} result.
+func decode[T any](t *testing.T, p *T, v interface{}) {
+	t.Helper()
+	s := goString(p)
+	var e struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal([]byte(s), &e) == nil && e.Error != "" {
+		t.Fatalf("export failed: %s", e.Error)
+	}
+	if err := json.Unmarshal([]byte(s), v); err != nil {
+		t.Fatalf("decode %s: %v", s, err)
+	}
+}
+
+// newTestNetwork builds a 2-4-3 CPU network and returns its handle, freed
+// when the test ends.
+func newTestNetwork(t *testing.T) int64 {
+	t.Helper()
+	var r struct {
+		Handle int64 `json:"handle"`
+	}
+	decode(t, Paragon_NewNetworkFloat32(
+		cstr(`[{"Width":2,"Height":1},{"Width":4,"Height":1},{"Width":3,"Height":1}]`),
+		cstr(`["linear","relu","linear"]`),
+		cstr(`[true,true,true]`),
+		false, false,
+	), &r)
+	t.Cleanup(func() { Paragon_Free(r.Handle) })
+	return r.Handle
+}
+
+// tally has a pointer-receiver write and a value-receiver read, so
+// Paragon_CallBatchConcurrent serializes the one and parallelizes the other.
+type tally struct{ n int }
+
+func (c *tally) Add(d int) int { c.n += d; return c.n }
+func (c tally) Value() int     { return c.n }
+
+func TestCallBatchConcurrent(t *testing.T) {
+	counter := put(&tally{})
+	t.Cleanup(func() { Paragon_Free(counter) })
+	h1, h2 := newTestNetwork(t), newTestNetwork(t)
+
+	type call struct {
+		Handle int64         `json:"handle"`
+		Method string        `json:"method"`
+		Args   []interface{} `json:"args"`
+	}
+	var calls []call
+	want := map[int]float64{}
+	sum := 0
+	for i := 1; i <= 20; i++ {
+		sum += i
+		want[len(calls)] = float64(sum)
+		calls = append(calls,
+			call{counter, "Add", []interface{}{i}},
+			call{counter, "Value", nil},
+			call{h1, "Forward", []interface{}{[][]float64{{float64(i), 1}}}},
+			call{h2, "Forward", []interface{}{[][]float64{{1, float64(i)}}}},
+		)
+	}
+	calls = append(calls, call{counter, "Missing", nil})
+	b, _ := json.Marshal(calls)
+
+	var r struct {
+		Results []json.RawMessage `json:"results"`
+	}
+	decode(t, Paragon_CallBatchConcurrent(cstr(string(b))), &r)
+	if len(r.Results) != len(calls) {
+		t.Fatalf("got %d results for %d calls", len(r.Results), len(calls))
+	}
+	// Writes on one handle run in input order, so each Add sees the sum so far.
+	for i, w := range want {
+		var out []float64
+		if err := json.Unmarshal(r.Results[i], &out); err != nil || len(out) != 1 || out[0] != w {
+			t.Errorf("result %d = %s, want [%v]", i, r.Results[i], w)
+		}
+	}
+	if last := string(r.Results[len(calls)-1]); !strings.Contains(last, "Method not found") {
+		t.Errorf("unknown method result = %s", last)
+	}
+	if e, _ := getEntry(counter); e.obj.(*tally).n != sum {
+		t.Errorf("final count = %d, want %d", e.obj.(*tally).n, sum)
+	}
+}
+
+// weightsOf returns the handle's parameters in the flatWeights layout.
+func weightsOf(t *testing.T, handle int64) []float64 {
+	t.Helper()
+	net, err := getNetwork(handle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return flatWeights(net)
+}
+
+func sameWeights(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if float32(a[i]) != float32(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// setDistinctWeights gives every weight and bias of the handle a different
+// value, so a layout mix-up cannot go unnoticed.
+func setDistinctWeights(t *testing.T, handle int64) {
+	t.Helper()
+	net, err := getNetwork(handle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vals := make([]float32, paramCount(net))
+	for i := range vals {
+		vals[i] = float32(i)*0.01 - 0.1
+	}
+	if err := loadFlatWeights(net, vals); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWeightsBase64RoundTrip(t *testing.T) {
+	h := newTestNetwork(t)
+	setDistinctWeights(t, h)
+	want := weightsOf(t, h)
+
+	var exported struct {
+		Data  string `json:"data"`
+		Count int    `json:"count"`
+	}
+	decode(t, Paragon_ExportWeightsBase64(h), &exported)
+	if exported.Count != len(want) {
+		t.Fatalf("count = %d, want %d", exported.Count, len(want))
+	}
+	decode(t, Paragon_PerturbWeights(h, 0.5, 1), &struct{}{})
+	decode(t, Paragon_ImportWeightsBase64(h, cstr(exported.Data)), &struct{}{})
+	if got := weightsOf(t, h); !sameWeights(got, want) {
+		t.Errorf("round trip changed the weights:\n got %v\nwant %v", got, want)
+	}
+}
+
+func TestImportWeightsRejectsNegativeLength(t *testing.T) {
+	h := newTestNetwork(t)
+	buf := Paragon_ExportWeights(h, nil)
+	defer Paragon_FreeFloatBuffer(buf)
+
+	var r struct {
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(goString(Paragon_ImportWeights(h, buf, -1))), &r); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(r.Error, "length must be >= 0") {
+		t.Errorf("ImportWeights with length -1 = %q, want a length error", r.Error)
+	}
+}
+
+// TestGPUFallbackForward marks a CPU network as GPU-backed without giving it
+// any GPU state, so the GPU forward pass fails the way it does when a device
+// is lost, and checks that Forward is retried on the CPU and says so.
+func TestGPUFallbackForward(t *testing.T) {
+	h, ref := newTestNetwork(t), newTestNetwork(t)
+	setDistinctWeights(t, h)
+	setDistinctWeights(t, ref)
+	net, err := getNetwork(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	net.WebGPUNative = true
+	t.Cleanup(func() { net.WebGPUNative = false })
+	decode(t, Paragon_SetGPUFallback(h, true), &struct{}{})
+
+	args := cstr(`[[[0.5,-0.25]]]`)
+	var r struct {
+		FellBack bool   `json:"fell_back_to_cpu"`
+		GPUError string `json:"gpu_error"`
+	}
+	decode(t, Paragon_Call(h, cstr("Forward"), args), &r)
+	if !r.FellBack || r.GPUError == "" {
+		t.Fatalf("Forward did not report a CPU fallback: %+v", r)
+	}
+
+	decode(t, Paragon_Call(ref, cstr("Forward"), args), &[]interface{}{})
+	var got, want [][]float64
+	decode(t, Paragon_Call(h, cstr("ExtractOutput"), cstr("[]")), &got)
+	decode(t, Paragon_Call(ref, cstr("ExtractOutput"), cstr("[]")), &want)
+	if !sameWeights(got[0], want[0]) {
+		t.Errorf("fallback output %v, CPU output %v", got[0], want[0])
+	}
+}
+
+func fingerprint(t *testing.T, handle int64) string {
+	t.Helper()
+	var r struct {
+		Fingerprint string `json:"fingerprint"`
+	}
+	decode(t, Paragon_GetNetworkFingerprint(handle), &r)
+	return r.Fingerprint
+}
+
+func TestNetworkFingerprint(t *testing.T) {
+	h, clone := newTestNetwork(t), newTestNetwork(t)
+	var exported struct {
+		Data string `json:"data"`
+	}
+	decode(t, Paragon_ExportWeightsBase64(h), &exported)
+	decode(t, Paragon_ImportWeightsBase64(clone, cstr(exported.Data)), &struct{}{})
+
+	if a, b := fingerprint(t, h), fingerprint(t, clone); a != b {
+		t.Fatalf("clone fingerprint %s differs from original %s", b, a)
+	}
+	decode(t, Paragon_PerturbWeights(clone, 0.01, 1), &struct{}{})
+	if a, b := fingerprint(t, h), fingerprint(t, clone); a == b {
+		t.Error("perturbed clone still has the original's fingerprint")
+	}
+}
+
+func TestPruneWeightsShowsInSparsity(t *testing.T) {
+	h := newTestNetwork(t)
+	const threshold = 0.5
+	type sparsity struct {
+		Weights int `json:"weights"`
+		Below   int `json:"below"`
+	}
+	var before sparsity
+	decode(t, Paragon_GetWeightSparsity(h, threshold), &before)
+	if before.Below == 0 {
+		t.Fatal("no weights below the threshold to prune")
+	}
+
+	var pruned struct {
+		Pruned int `json:"pruned"`
+	}
+	decode(t, Paragon_PruneWeights(h, threshold), &pruned)
+	if pruned.Pruned != before.Below {
+		t.Errorf("pruned %d weights, sparsity report had %d below %v", pruned.Pruned, before.Below, threshold)
+	}
+
+	// Pruned weights are exactly zero: below any positive threshold.
+	var zeros sparsity
+	decode(t, Paragon_GetWeightSparsity(h, 1e-30), &zeros)
+	if zeros.Below != before.Below {
+		t.Errorf("%d weights are zero after pruning, want %d", zeros.Below, before.Below)
+	}
+	var after sparsity
+	decode(t, Paragon_GetWeightSparsity(h, threshold), &after)
+	if after != before {
+		t.Errorf("sparsity at %v changed from %+v to %+v", threshold, before, after)
+	}
+}
+
+func TestWeightsNPYRoundTrip(t *testing.T) {
+	src, dst := newTestNetwork(t), newTestNetwork(t)
+	setDistinctWeights(t, src)
+	dir := t.TempDir()
+
+	var exported struct {
+		Files []string `json:"files"`
+		Dtype string   `json:"dtype"`
+	}
+	decode(t, Paragon_ExportWeightsNPY(src, cstr(dir)), &exported)
+	if exported.Dtype != "float32" || len(exported.Files) != 4 {
+		t.Fatalf("exported %v as %s, want 4 float32 files", exported.Files, exported.Dtype)
+	}
+	decode(t, Paragon_ImportWeightsNPY(dst, cstr(dir)), &struct{}{})
+	if got, want := weightsOf(t, dst), weightsOf(t, src); !sameWeights(got, want) {
+		t.Errorf("NPY round trip changed the weights:\n got %v\nwant %v", got, want)
+	}
+
+	// A network with a different hidden width must be refused untouched.
+	var other struct {
+		Handle int64 `json:"handle"`
+	}
+	decode(t, Paragon_NewNetworkFloat32(
+		cstr(`[{"Width":2,"Height":1},{"Width":5,"Height":1},{"Width":3,"Height":1}]`),
+		cstr(`["linear","relu","linear"]`),
+		cstr(`[true,true,true]`),
+		false, false,
+	), &other)
+	t.Cleanup(func() { Paragon_Free(other.Handle) })
+	before := weightsOf(t, other.Handle)
+	msg := goString(Paragon_ImportWeightsNPY(other.Handle, cstr(dir)))
+	if !strings.Contains(msg, "layer1_weights.npy") {
+		t.Errorf("shape mismatch error %s does not name the file", msg)
+	}
+	if !sameWeights(weightsOf(t, other.Handle), before) {
+		t.Error("a rejected import changed the weights")
+	}
+}
+
+// TestFrozenLayerKeepsWeights takes a momentum step, freezes the hidden
+// layer and steps again, when momentum alone would still move it, and checks
+// that only the unfrozen layer changed.
+func TestFrozenLayerKeepsWeights(t *testing.T) {
+	h := newTestNetwork(t)
+	setDistinctWeights(t, h)
+	decode(t, Paragon_SetOptimizer(h, cstr("momentum"), cstr("")), &struct{}{})
+	in, target := floatBuf([]float64{-1, -0.5}), floatBuf([]float64{0.5, -0.25, 1})
+	defer Paragon_FreeFloatBuffer(in)
+	defer Paragon_FreeFloatBuffer(target)
+	decode(t, Paragon_TrainStep(h, in, 2, target, 3, 0.1), &struct{}{})
+
+	var r struct {
+		Trainable int `json:"trainable"`
+	}
+	decode(t, Paragon_SetLayerFrozen(h, 1, true), &r)
+	if want := 4*3 + 3; r.Trainable != want {
+		t.Errorf("trainable = %d, want %d", r.Trainable, want)
+	}
+	before := weightsOf(t, h)
+	decode(t, Paragon_TrainStep(h, in, 2, target, 3, 0.1), &struct{}{})
+	after := weightsOf(t, h)
+
+	const hidden = 2*4 + 4 // layer 1's weights and biases come first
+	if !sameWeights(after[:hidden], before[:hidden]) {
+		t.Errorf("frozen layer changed:\n got %v\nwant %v", after[:hidden], before[:hidden])
+	}
+	if sameWeights(after[hidden:], before[hidden:]) {
+		t.Error("unfrozen output layer did not train")
+	}
+}
+
+func TestSnapshotBufferRoundTrip(t *testing.T) {
+	h := newTestNetwork(t)
+	setDistinctWeights(t, h)
+	want := weightsOf(t, h)
+	// 2x4 weights + 4 biases, then 4x3 weights + 3 biases.
+	const params = 27
+	if len(want) != params {
+		t.Fatalf("test network has %d parameters, want %d", len(want), params)
+	}
+
+	buf := Paragon_SnapshotToBuffer(h, nil)
+	if buf == nil {
+		t.Fatal("SnapshotToBuffer returned NULL")
+	}
+	defer Paragon_FreeFloatBuffer(buf)
+	for i, v := range unsafe.Slice((*float32)(unsafe.Pointer(buf)), params) {
+		if v != float32(want[i]) {
+			t.Fatalf("snapshot[%d] = %v, want %v", i, v, want[i])
+		}
+	}
+
+	decode(t, Paragon_ReinitializeWeights(h, cstr("normal"), 1), &struct{}{})
+	changed := weightsOf(t, h)
+	if msg := goString(Paragon_RestoreFromBuffer(h, buf, params-1)); !strings.Contains(msg, "error") {
+		t.Errorf("short buffer accepted: %s", msg)
+	}
+	if !sameWeights(weightsOf(t, h), changed) {
+		t.Error("a rejected restore changed the weights")
+	}
+
+	decode(t, Paragon_RestoreFromBuffer(h, buf, params), &struct{}{})
+	if got := weightsOf(t, h); !sameWeights(got, want) {
+		t.Errorf("restore gave\n %v\nwant %v", got, want)
+	}
+}
+
+func TestRetainKeepsHandleUntilLastFree(t *testing.T) {
+	h := newTestNetwork(t)
+	var r struct {
+		Refcount int `json:"refcount"`
+	}
+	decode(t, Paragon_Retain(h), &r)
+	if r.Refcount != 2 {
+		t.Fatalf("refcount after retain = %d, want 2", r.Refcount)
+	}
+	Paragon_Free(h)
+	decode(t, Paragon_GetRefCount(h), &r)
+	if r.Refcount != 1 {
+		t.Fatalf("refcount after one free = %d, want 1", r.Refcount)
+	}
+	Paragon_Free(h)
+	if _, ok := getEntry(h); ok {
+		t.Error("handle still live after its last owner freed it")
+	}
+}
+
+// TestSharedTemplateSerializesTraining trains a weight-sharing clone with
+// DropConnect, which rewrites the shared weights during each step, while
+// forwarding the template; under -race this fails unless the two handles
+// share a lock.
+func TestSharedTemplateSerializesTraining(t *testing.T) {
+	h := newTestNetwork(t)
+	var clone struct {
+		Handle int64 `json:"handle"`
+	}
+	decode(t, Paragon_CreateFromTemplate(h, true), &clone)
+	t.Cleanup(func() { Paragon_Free(clone.Handle) })
+	decode(t, Paragon_SetDropConnect(clone.Handle, 0.5, 1), &struct{}{})
+
+	in, target := floatBuf([]float64{1, -0.5}), floatBuf([]float64{0.5, -0.25, 1})
+	defer Paragon_FreeFloatBuffer(in)
+	defer Paragon_FreeFloatBuffer(target)
+	done := make(chan string)
+	go func() {
+		defer close(done)
+		for range 20 {
+			if r := goString(Paragon_TrainStep(clone.Handle, in, 2, target, 3, 0.01)); strings.Contains(r, `"error"`) {
+				done <- r
+				return
+			}
+		}
+	}()
+	for range 20 {
+		decode(t, Paragon_Call(h, cstr("Forward"), cstr(`[[[1,-0.5]]]`)), &[]interface{}{})
+	}
+	if r, failed := <-done; failed {
+		t.Fatalf("TrainStep on the clone failed: %s", r)
+	}
+}
+
+// connectionNorm is the L2 norm of the handle's connection weights, biases
+// excluded.
+func connectionNorm(t *testing.T, handle int64) float64 {
+	t.Helper()
+	net, err := getNetwork(handle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := 0.0
+	for l := net.InputLayer + 1; l < len(net.Layers); l++ {
+		for _, row := range net.Layers[l].Neurons {
+			for _, neuron := range row {
+				for _, c := range neuron.Inputs {
+					sum += float64(c.Weight) * float64(c.Weight)
+				}
+			}
+		}
+	}
+	return math.Sqrt(sum)
+}
+
+func TestWeightDecayShrinksWeights(t *testing.T) {
+	const lr, lambda = 0.05, 2.0
+	in := [][][]float64{{{1, -0.5}}}
+	train := func(handle int64, target [][][]float64) {
+		t.Helper()
+		net, _ := getNetwork(handle)
+		e, _ := getEntry(handle)
+		if _, err := trainBatch(e, net, in, target, lr); err != nil {
+			t.Fatal(err)
+		}
+	}
+	output := func(handle int64) [][][]float64 {
+		net, _ := getNetwork(handle)
+		forwardCPU(net, nil, in[0], nil)
+		return [][][]float64{{net.GetOutput()}}
+	}
+
+	// With the target equal to the output the loss gradient is zero, so one
+	// SGD step scales every connection weight by 1 - lr·lambda.
+	h := newTestNetwork(t)
+	setDistinctWeights(t, h)
+	decode(t, Paragon_SetWeightDecay(h, lambda), &struct{}{})
+	net, _ := getNetwork(h)
+	before := flatWeights(net)
+	train(h, output(h))
+	after := flatWeights(net)
+	i := 0
+	for l := net.InputLayer + 1; l < len(net.Layers); l++ {
+		for _, row := range net.Layers[l].Neurons {
+			for _, neuron := range row {
+				for range neuron.Inputs {
+					if want := before[i] * (1 - lr*lambda); math.Abs(after[i]-want) > 1e-6 {
+						t.Fatalf("weight %d = %v, want %v", i, after[i], want)
+					}
+					i++
+				}
+			}
+		}
+		for range net.Layers[l].Width * net.Layers[l].Height {
+			if after[i] != before[i] {
+				t.Fatalf("bias %d decayed from %v to %v", i, before[i], after[i])
+			}
+			i++
+		}
+	}
+
+	// Trained on the same data, a decayed network ends up with smaller
+	// weights than an undecayed one.
+	plain, decayed := newTestNetwork(t), newTestNetwork(t)
+	setDistinctWeights(t, plain)
+	setDistinctWeights(t, decayed)
+	decode(t, Paragon_SetWeightDecay(decayed, lambda), &struct{}{})
+	target := [][][]float64{{{0.5, -0.25, 1}}}
+	start := connectionNorm(t, decayed)
+	for range 50 {
+		train(plain, target)
+		train(decayed, target)
+	}
+	if p, d := connectionNorm(t, plain), connectionNorm(t, decayed); !(d < p && d < start) {
+		t.Errorf("weight norm with decay %v, without %v, initially %v", d, p, start)
+	}
+}